@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/site"
+)
+
+// loadSiteConfig loads the site configuration file of the command, falling
+// back to the defaults if it is not set explicitly and does not exist. The
+// base URL flag takes precedence over the file.
+func loadSiteConfig(c *cli.Context) (*site.Config, error) {
+	config, err := site.LoadConfig(c.String("config"))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) || c.IsSet("config") {
+			return nil, err
+		}
+		config = site.DefaultConfig()
+	}
+
+	if u := c.String("base-url"); u != "" {
+		config.BaseURL = u
+	}
+	return config, nil
+}
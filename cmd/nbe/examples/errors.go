@@ -0,0 +1,44 @@
+package examples
+
+import "strings"
+
+// MultiErr is a list of errors reported together, e.g. the errors parsing
+// each example.
+type MultiErr []error
+
+func (m *MultiErr) Empty() bool {
+	for _, err := range *m {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Append adds a non-nil error. If err is itself a MultiErr, its errors are
+// appended individually.
+func (m *MultiErr) Append(err error) {
+	if err == nil {
+		return
+	}
+	if me, ok := err.(*MultiErr); ok {
+		for _, err := range *me {
+			m.Append(err)
+		}
+		return
+	}
+	*m = append(*m, err)
+}
+
+func (m *MultiErr) Error() string {
+	var toks []string
+	for _, err := range *m {
+		if err != nil {
+			toks = append(toks, err.Error())
+		}
+	}
+	if len(toks) > 0 {
+		return strings.Join(toks, "\n")
+	}
+	return ""
+}
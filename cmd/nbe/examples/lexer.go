@@ -0,0 +1,392 @@
+package examples
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+// Trailing characters indicating a line of code continues on the next line.
+const continuationChars = "([{,=+\\"
+
+var heredocRe = regexp.MustCompile(`^<<(-?)\s*(['"]?)([A-Za-z_][A-Za-z0-9_]*)(['"]?)`)
+
+// lexer classifies lines of source code, one at a time, carrying state across
+// lines for multi-line comments, strings and heredocs. Unlike a regex per line,
+// this correctly handles comment delimiters within string literals and block
+// comments which start after code on the same line.
+type lexer struct {
+	syn *lang.Syntax
+
+	// Nesting depth of the current block comment, zero if not in one.
+	depth int
+	// Delimiters of the current block comment.
+	blockOpen  string
+	blockClose string
+	// If true, the current block comment can be nested.
+	nested bool
+	// If true, the close delimiter of the current block comment must be at
+	// the start of a line.
+	lineStart bool
+	// True if the current block comment started after code on the same line.
+	// These are considered part of the code rather than a comment block.
+	trailing bool
+
+	// True if the last line of code is continued on the next line, e.g. it
+	// ended with an open paren. Doc comments are not recognized here.
+	cont bool
+
+	// The current multi-line string literal, if any.
+	str      *lang.StringSyntax
+	strClose string
+
+	// Terminator of the current heredoc, if any.
+	heredoc     string
+	heredocTrim bool
+}
+
+func newLexer(syn *lang.Syntax) *lexer {
+	return &lexer{syn: syn}
+}
+
+// Next classifies the next line of the source.
+func (l *lexer) Next(line string) LineType {
+	if strings.TrimSpace(line) == "" {
+		return EmptyLine
+	}
+
+	// Heredoc bodies are opaque, only the terminator is significant.
+	if l.heredoc != "" {
+		t := line
+		if l.heredocTrim {
+			t = strings.TrimLeft(t, "\t")
+		}
+		if t == l.heredoc {
+			l.heredoc = ""
+		}
+		return NormalLine
+	}
+
+	if l.str == nil && isBlockBreak(line) {
+		l.scan(line)
+		return BreakLine
+	}
+
+	if l.syn.Shebang && strings.HasPrefix(line, "#!") {
+		return NormalLine
+	}
+
+	inString := l.str != nil
+	inComment := l.depth > 0 && !l.trailing
+
+	s := l.scan(line)
+
+	switch {
+	case inString:
+		return NormalLine
+
+	case inComment:
+		if s.closed {
+			return CloseMultiCommentLine
+		}
+		return NormalLine
+
+	case s.stray:
+		return CloseMultiCommentLine
+
+	case s.opened:
+		// Opened and closed on the same line, e.g. /* meh */
+		if l.depth == 0 {
+			return NormalLine
+		}
+		return OpenMultiCommentLine
+
+	case s.comment && !s.code:
+		if isDirective(line) {
+			return DirectiveLine
+		}
+		return SingleCommentLine
+	}
+
+	return NormalLine
+}
+
+type scanResult struct {
+	// Code, excluding comments and whitespace, is present.
+	code bool
+	// A line comment is present.
+	comment bool
+	// A block comment was opened with no code preceding it.
+	opened bool
+	// A block comment open at the start of the line was closed.
+	closed bool
+	// A block comment close delimiter was found outside of a comment.
+	stray bool
+}
+
+func (l *lexer) scan(line string) scanResult {
+	var (
+		r       scanResult
+		heredoc string
+		trim    bool
+		last    byte
+	)
+
+	for i := 0; i < len(line); {
+		rest := line[i:]
+
+		// Within a string literal, only an escape or the close delimiter
+		// are significant.
+		if l.str != nil {
+			if l.str.Escape != "" && strings.HasPrefix(rest, l.str.Escape) {
+				i += len(l.str.Escape) + 1
+				continue
+			}
+			if strings.HasPrefix(rest, l.strClose) {
+				i += len(l.strClose)
+				l.str = nil
+				continue
+			}
+			i++
+			continue
+		}
+
+		// Within a block comment, only nested open delimiters and the close
+		// delimiter are significant.
+		if l.depth > 0 {
+			if l.nested && strings.HasPrefix(rest, l.blockOpen) {
+				l.depth++
+				i += len(l.blockOpen)
+				continue
+			}
+			if strings.HasPrefix(rest, l.blockClose) && (!l.lineStart || i == 0) {
+				l.depth--
+				i += len(l.blockClose)
+				if l.depth == 0 {
+					if !l.trailing {
+						r.closed = true
+					}
+					l.trailing = false
+				}
+				continue
+			}
+			i++
+			continue
+		}
+
+		c := line[i]
+		if c == ' ' || c == '\t' || c == '\r' {
+			i++
+			continue
+		}
+
+		if !r.code && !l.cont {
+			if d, ok := l.matchDocComment(line, i); ok {
+				if d.Close == "" {
+					r.comment = true
+					break
+				}
+				l.depth = 1
+				l.blockOpen = d.Open
+				l.blockClose = d.Close
+				l.nested = false
+				l.lineStart = d.LineStart
+				l.trailing = false
+				r.opened = true
+				i += len(d.Open)
+				continue
+			}
+		}
+
+		if l.isLineComment(line, i) {
+			r.comment = true
+			break
+		}
+
+		if open, close, ok := l.matchBlockOpen(rest); ok {
+			l.depth = 1
+			l.blockOpen = open
+			l.blockClose = close
+			l.nested = l.syn.NestedComments
+			l.lineStart = false
+			l.trailing = r.code
+			if !r.code {
+				r.opened = true
+			}
+			i += len(open)
+			continue
+		}
+
+		if n := l.matchBlockClose(rest); n > 0 {
+			r.stray = true
+			r.code = true
+			i += n
+			continue
+		}
+
+		if n, ok := l.matchString(line, i); ok {
+			r.code = true
+			last = 0
+			i += n
+			continue
+		}
+
+		if l.syn.Heredocs && strings.HasPrefix(rest, "<<") && !strings.HasPrefix(rest, "<<<") {
+			if m := heredocRe.FindStringSubmatch(rest); m != nil && m[2] == m[4] {
+				heredoc = m[3]
+				trim = m[1] == "-"
+				r.code = true
+				last = 0
+				i += len(m[0])
+				continue
+			}
+		}
+
+		r.code = true
+		last = c
+		_, n := utf8.DecodeRuneInString(rest)
+		i += n
+	}
+
+	if r.code {
+		l.cont = l.str == nil && l.depth == 0 && strings.IndexByte(continuationChars, last) >= 0
+	}
+
+	// Unterminated single-line strings end with the line.
+	if l.str != nil && !l.str.Multiline {
+		l.str = nil
+	}
+
+	if heredoc != "" {
+		l.heredoc = heredoc
+		l.heredocTrim = trim
+	}
+
+	return r
+}
+
+func (l *lexer) isLineComment(line string, i int) bool {
+	for _, d := range l.syn.LineComments {
+		if !strings.HasPrefix(line[i:], d) {
+			continue
+		}
+		if !l.syn.WordComments || i == 0 {
+			return true
+		}
+		switch line[i-1] {
+		case ' ', '\t', ';', '|', '&', '(', ')':
+			return true
+		}
+	}
+	return false
+}
+
+func (l *lexer) matchDocComment(line string, i int) (*lang.DocComment, bool) {
+	for j := range l.syn.DocComments {
+		d := &l.syn.DocComments[j]
+		if d.LineStart && i > 0 {
+			continue
+		}
+		if strings.HasPrefix(line[i:], d.Open) {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+func (l *lexer) matchBlockOpen(s string) (string, string, bool) {
+	for _, d := range l.syn.BlockComments {
+		if strings.HasPrefix(s, d[0]) {
+			return d[0], d[1], true
+		}
+	}
+	return "", "", false
+}
+
+func (l *lexer) matchBlockClose(s string) int {
+	for _, d := range l.syn.BlockComments {
+		if strings.HasPrefix(s, d[1]) {
+			return len(d[1])
+		}
+	}
+	return 0
+}
+
+// matchString checks if a string literal starts at position i. If the literal
+// is terminated on the same line, it is consumed entirely, otherwise the lexer
+// state is updated to track the open literal. The number of bytes consumed
+// is returned.
+func (l *lexer) matchString(line string, i int) (int, bool) {
+	rest := line[i:]
+	for j := range l.syn.Strings {
+		s := &l.syn.Strings[j]
+		if !strings.HasPrefix(rest, s.Open) {
+			continue
+		}
+
+		// Prefixed literals, e.g. r"..", must not be part of an identifier.
+		if isIdentByte(s.Open[0]) && i > 0 && isIdentByte(line[i-1]) {
+			continue
+		}
+
+		n := len(s.Open)
+		close := s.Close
+
+		if s.Hashes {
+			h := 0
+			for n+h < len(rest) && rest[n+h] == '#' {
+				h++
+			}
+			if n+h >= len(rest) || rest[n+h] != '"' {
+				continue
+			}
+			n += h + 1
+			close = s.Close + strings.Repeat("#", h)
+		}
+
+		if s.Char {
+			m := matchCharLiteral(rest, s)
+			if m == 0 {
+				continue
+			}
+			return m, true
+		}
+
+		l.str = s
+		l.strClose = close
+		return n, true
+	}
+	return 0, false
+}
+
+// matchCharLiteral returns the length of the char literal at the start of s,
+// or zero if s does not start with one.
+func matchCharLiteral(s string, syn *lang.StringSyntax) int {
+	i := len(syn.Open)
+	if i >= len(s) {
+		return 0
+	}
+	if syn.Escape != "" && strings.HasPrefix(s[i:], syn.Escape) {
+		// Escape sequences vary in length, e.g. '\n', '\x7f', '\u{1F600}'.
+		j := i + len(syn.Escape) + 1
+		if j > len(s) {
+			return 0
+		}
+		end := strings.Index(s[j:], syn.Close)
+		if end < 0 {
+			return 0
+		}
+		return j + end + len(syn.Close)
+	}
+	_, n := utf8.DecodeRuneInString(s[i:])
+	if !strings.HasPrefix(s[i+n:], syn.Close) {
+		return 0
+	}
+	return i + n + len(syn.Close)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,335 @@
+package examples
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+// Keys supported in each kind of meta.yaml, see the README.
+var (
+	rootMetaKeys     = []string{"categories"}
+	categoryMetaKeys = []string{"title", "description", "examples"}
+	exampleMetaKeys  = []string{"title", "description", "files", "tags", "level", "requires", "min_server_version", "related", "features", "added"}
+)
+
+// Names of the lint rules.
+const (
+	LintParse       = "parse"
+	LintMeta        = "meta"
+	LintMetaKey     = "meta-key"
+	LintOrdering    = "ordering"
+	LintMainFile    = "main-file"
+	LintOutput      = "output"
+	LintDescription = "description"
+	LintLanguage    = "language"
+	LintFiles       = "files"
+)
+
+// LintIssue is a problem found in the examples tree.
+type LintIssue struct {
+	// File or directory the issue relates to, optionally with a line number.
+	Path string `json:"path"`
+	// Name of the rule, e.g. meta-key.
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (i *LintIssue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Path, i.Message, i.Rule)
+}
+
+type linter struct {
+	langs  *lang.Registry
+	issues []*LintIssue
+}
+
+func (l *linter) add(path, rule, format string, args ...any) {
+	l.issues = append(l.issues, &LintIssue{
+		Path:    path,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Lint validates the examples tree at path. In addition to the errors
+// returned by Parse, it reports the problems parsing skips over, such as
+// ordering entries for directories that do not exist.
+func (p *Parser) Lint(path string) ([]*LintIssue, error) {
+	l := linter{langs: p.languages()}
+
+	// Skipped clients are reported as issues instead.
+	q := *p
+	q.Logger = log.New(io.Discard, "", 0)
+	if _, err := q.Parse(path); err != nil {
+		errs, ok := err.(*MultiErr)
+		if !ok {
+			errs = &MultiErr{err}
+		}
+		for _, err := range *errs {
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				p := perr.Path
+				if perr.Line > 0 {
+					p = fmt.Sprintf("%s:%d", p, perr.Line)
+				}
+				l.add(p, LintParse, "%s", perr.Reason)
+				continue
+			}
+			l.add(path, LintParse, "%s", err)
+		}
+	}
+
+	if err := l.lintRoot(path); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Path < l.issues[j].Path
+	})
+
+	return l.issues, nil
+}
+
+func (l *linter) lintRoot(path string) error {
+	var meta struct {
+		Categories []string
+	}
+	if !l.readMeta(path, rootMetaKeys, &meta) {
+		return nil
+	}
+
+	dirs, err := subdirs(path)
+	if err != nil {
+		return err
+	}
+
+	l.lintOrdering(path, "categories", meta.Categories, dirs)
+
+	for _, name := range dirs {
+		l.lintCategory(filepath.Join(path, name))
+	}
+	return nil
+}
+
+func (l *linter) lintCategory(path string) {
+	var meta struct {
+		Examples []string
+	}
+	if !l.readMeta(path, categoryMetaKeys, &meta) {
+		return
+	}
+
+	dirs, err := subdirs(path)
+	if err != nil {
+		l.add(path, LintParse, "%s", err)
+		return
+	}
+
+	l.lintOrdering(path, "examples", meta.Examples, dirs)
+
+	for _, name := range dirs {
+		l.lintExample(filepath.Join(path, name))
+	}
+}
+
+func (l *linter) lintExample(path string) {
+	var meta struct {
+		Description string
+		Files       map[string][]string
+	}
+	if !l.readMeta(path, exampleMetaKeys, &meta) {
+		return
+	}
+
+	if strings.TrimSpace(meta.Description) == "" {
+		l.add(path, LintDescription, "example has no description")
+	}
+
+	dirs, err := subdirs(path)
+	if err != nil {
+		l.add(path, LintParse, "%s", err)
+		return
+	}
+
+	clients := make(map[string]bool)
+	for _, name := range dirs {
+		clients[name] = true
+		l.lintClient(filepath.Join(path, name), name)
+	}
+
+	for name := range meta.Files {
+		if !clients[name] {
+			l.add(filepath.Join(path, "meta.yaml"), LintFiles, "files listed for client %q which does not exist", name)
+		}
+	}
+}
+
+func (l *linter) lintClient(path, name string) {
+	language, ok := l.langs.Get(strings.ToLower(name))
+	if !ok {
+		l.add(path, LintLanguage, "%q is not a known language", name)
+	}
+
+	// Same fallback as ParseClient.
+	mainFile := "main.sh"
+	if ok && language.Main != "" {
+		mainFile = language.Main
+	}
+	if !fileExists(filepath.Join(path, mainFile)) {
+		l.add(path, LintMainFile, "main file %s does not exist", mainFile)
+		return
+	}
+
+	for _, n := range []string{"output.txt", "output.cast"} {
+		if !fileExists(filepath.Join(path, n)) {
+			l.add(path, LintOutput, "%s does not exist", n)
+		}
+	}
+}
+
+// lintOrdering checks each entry of the ordering key refers to a directory.
+func (l *linter) lintOrdering(path, key string, names, dirs []string) {
+	exists := make(map[string]bool, len(dirs))
+	for _, d := range dirs {
+		exists[d] = true
+	}
+	for _, n := range names {
+		if !exists[n] {
+			l.add(filepath.Join(path, "meta.yaml"), LintOrdering, "%s entry %q does not exist", key, n)
+		}
+	}
+}
+
+// readMeta decodes the meta.yaml in the directory into v, if present, and
+// reports any keys which are not supported. False is returned if the file
+// could not be decoded.
+func (l *linter) readMeta(dir string, keys []string, v any) bool {
+	path := filepath.Join(dir, "meta.yaml")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return true
+		}
+		l.add(path, LintMeta, "%s", err)
+		return false
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		l.add(path, LintMeta, "%s", err)
+		return false
+	}
+	// Empty file.
+	if len(node.Content) == 0 {
+		return true
+	}
+
+	doc := node.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		l.add(path, LintMeta, "expected a mapping at the top level")
+		return false
+	}
+
+	for i := 0; i < len(doc.Content); i += 2 {
+		k := doc.Content[i]
+		if contains(keys, k.Value) {
+			continue
+		}
+		p := fmt.Sprintf("%s:%d", path, k.Line)
+		if s := closestKey(k.Value, keys); s != "" {
+			l.add(p, LintMetaKey, "unknown key %q, did you mean %q?", k.Value, s)
+		} else {
+			l.add(p, LintMetaKey, "unknown key %q", k.Value)
+		}
+	}
+
+	if err := doc.Decode(v); err != nil {
+		l.add(path, LintMeta, "%s", err)
+		return false
+	}
+	return true
+}
+
+// closestKey returns the key within an edit distance of two of s, if any.
+func closestKey(s string, keys []string) string {
+	var (
+		best string
+		dist = 3
+	)
+	for _, k := range keys {
+		if d := editDistance(strings.ToLower(s), k); d < dist {
+			best, dist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a int, bs ...int) int {
+	for _, b := range bs {
+		if b < a {
+			a = b
+		}
+	}
+	return a
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// subdirs returns the names of the directories within path.
+func subdirs(path string) ([]string, error) {
+	entries, err := fs.ReadDir(os.DirFS(path), ".")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
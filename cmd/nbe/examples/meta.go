@@ -0,0 +1,159 @@
+package examples
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Difficulty levels of an example.
+const (
+	LevelBeginner     = "beginner"
+	LevelIntermediate = "intermediate"
+	LevelAdvanced     = "advanced"
+)
+
+// DateLayout is the layout of the dates in meta.yaml files.
+const DateLayout = "2006-01-02"
+
+var (
+	tagRe     = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	versionRe = regexp.MustCompile(`^v?\d+(\.\d+){0,2}$`)
+)
+
+// validateMeta checks the values of the structured meta.yaml fields. The
+// references to other examples are checked by validateRefs once the whole
+// tree is parsed.
+func (e *Example) validateMeta() error {
+	path := filepath.Join(e.Path, "meta.yaml")
+	var errs MultiErr
+
+	switch e.Level {
+	case "", LevelBeginner, LevelIntermediate, LevelAdvanced:
+	default:
+		errs.Append(&ParseError{
+			Path:   path,
+			Reason: fmt.Sprintf("level %q must be one of %s, %s or %s", e.Level, LevelBeginner, LevelIntermediate, LevelAdvanced),
+		})
+	}
+
+	for _, t := range e.Tags {
+		if !tagRe.MatchString(t) {
+			errs.Append(&ParseError{
+				Path:   path,
+				Reason: fmt.Sprintf("tag %q must be lowercase words separated by dashes, e.g. key-value", t),
+			})
+		}
+	}
+
+	for _, f := range e.Features {
+		if strings.TrimSpace(f) == "" {
+			errs.Append(&ParseError{
+				Path:   path,
+				Reason: "features must not be empty",
+			})
+		}
+	}
+
+	if e.MinServerVersion != "" && !versionRe.MatchString(e.MinServerVersion) {
+		errs.Append(&ParseError{
+			Path:   path,
+			Reason: fmt.Sprintf("min_server_version %q must be a version, e.g. 2.10.0", e.MinServerVersion),
+		})
+	}
+
+	if e.Added != "" {
+		if _, err := time.Parse(DateLayout, e.Added); err != nil {
+			errs.Append(&ParseError{
+				Path:   path,
+				Reason: fmt.Sprintf("added %q must be a date, e.g. 2023-01-31", e.Added),
+			})
+		}
+	}
+
+	if errs.Empty() {
+		return nil
+	}
+	return &errs
+}
+
+// Example returns the example referenced as <category>/<example>, or nil if
+// it does not exist.
+func (r *Root) Example(ref string) *Example {
+	cat, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		return nil
+	}
+	for _, c := range r.Categories {
+		if c.Name != cat {
+			continue
+		}
+		for _, e := range c.Examples {
+			if e.Name == name {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// validateRefs checks the requires and related examples exist.
+func (r *Root) validateRefs() error {
+	var errs MultiErr
+	for _, c := range r.Categories {
+		for _, e := range c.Examples {
+			check := func(key string, refs []string) {
+				for _, ref := range refs {
+					if r.Example(ref) == nil {
+						errs.Append(&ParseError{
+							Path:   filepath.Join(e.Path, "meta.yaml"),
+							Reason: fmt.Sprintf("%s entry %q is not an example, expected <category>/<example>", key, ref),
+						})
+					}
+				}
+			}
+			check("requires", e.Requires)
+			check("related", e.Related)
+		}
+	}
+	if errs.Empty() {
+		return nil
+	}
+	return &errs
+}
+
+// SupportsServer returns false if the nats-server version is below the
+// minimum version of the example. Versions which cannot be compared, such as
+// latest, are assumed to be supported.
+func (e *Example) SupportsServer(version string) bool {
+	if e.MinServerVersion == "" || !versionRe.MatchString(version) {
+		return true
+	}
+	return compareVersions(version, e.MinServerVersion) >= 0
+}
+
+// compareVersions compares two versions matching versionRe, returning -1, 0
+// or 1. Missing minor and patch numbers are treated as zero.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < 3; i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,808 @@
+// Package examples parses the examples tree into categories, examples and
+// clients, with the source of each client split into comment and code blocks.
+package examples
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+// Parser parses the examples tree. The zero value is ready to use.
+type Parser struct {
+	// Languages used to parse the client sources. Defaults to lang.Default.
+	Languages *lang.Registry
+	// Logger for the clients which are skipped. Defaults to the standard
+	// logger.
+	Logger *log.Logger
+}
+
+func (p *Parser) languages() *lang.Registry {
+	if p.Languages == nil {
+		return lang.Default
+	}
+	return p.Languages
+}
+
+func (p *Parser) logf(format string, args ...any) {
+	if p.Logger == nil {
+		log.Printf(format, args...)
+		return
+	}
+	p.Logger.Printf(format, args...)
+}
+
+type Root struct {
+	Path       string
+	Categories []*Category
+}
+
+type Category struct {
+	Name        string
+	Path        string
+	Title       string
+	Description string
+	Examples    []*Example
+}
+
+type Example struct {
+	Name        string
+	Path        string
+	Title       string
+	Description string
+	Clients     map[string]*Client
+
+	// Additional source files by client name, relative to the client
+	// directory, e.g. `cli: [service/main.go]`.
+	Files map[string][]string
+
+	// Tags used to group related examples, e.g. consumers.
+	Tags []string `yaml:"tags"`
+	// Difficulty level, one of beginner, intermediate or advanced.
+	Level string `yaml:"level"`
+	// Examples to read before this one, as <category>/<example>.
+	Requires []string `yaml:"requires"`
+	// Minimum nats-server version the example runs against, e.g. 2.10.0.
+	MinServerVersion string `yaml:"min_server_version"`
+	// Related examples, as <category>/<example>.
+	Related []string `yaml:"related"`
+	// NATS features the example uses, e.g. JetStream or KV.
+	Features []string `yaml:"features"`
+	// Date the example was published, as YYYY-MM-DD. Defaults to the date of
+	// the first commit of the example.
+	Added string `yaml:"added"`
+}
+
+// SortedClients returns the clients in the order of the language tabs of the
+// registry, followed by the remaining clients sorted by name. If the registry
+// is nil, lang.Default is used.
+func (e *Example) SortedClients(langs *lang.Registry) []*Client {
+	if langs == nil {
+		langs = lang.Default
+	}
+	order := make(map[string]int)
+	for i, l := range langs.Tabs() {
+		order[l.Name] = i
+	}
+	rank := func(c *Client) int {
+		if i, ok := order[c.Name]; ok {
+			return i
+		}
+		return len(order)
+	}
+
+	cs := make([]*Client, 0, len(e.Clients))
+	for _, c := range e.Clients {
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if ri, rj := rank(cs[i]), rank(cs[j]); ri != rj {
+			return ri < rj
+		}
+		return cs[i].Name < cs[j].Name
+	})
+	return cs
+}
+
+type Client struct {
+	Name     string
+	Path     string
+	Language string
+	MainFile string
+	Blocks   []*Block
+	Source   string
+
+	// Additional source files rendered alongside the main file.
+	Files []*File
+}
+
+// File is an additional source file of a client.
+type File struct {
+	// Name of the file relative to the client directory.
+	Name string
+	// Language the file is parsed with, empty if not supported in which case
+	// the file is a single code block.
+	Language string
+	Blocks   []*Block
+	Source   string
+}
+
+type BlockType uint8
+
+const (
+	EmptyBlock BlockType = iota
+	BreakBlock
+	CodeBlock
+	SingleLineCommentBlock
+	MultiLineCommentBlock
+)
+
+type Block struct {
+	Type      BlockType
+	Lines     []string
+	StartLine int
+	EndLine   int
+
+	// If true, the block is part of the source but not rendered, as set by
+	// the <!hide> directive.
+	Hidden bool
+	// Title of the collapsed section the block is rendered in, as set by the
+	// <!collapse> directive.
+	Collapse string
+}
+
+type LineType uint8
+
+const (
+	EmptyLine LineType = iota
+	BreakLine
+	NormalLine
+	SingleCommentLine
+	OpenMultiCommentLine
+	CloseMultiCommentLine
+	DirectiveLine
+)
+
+// Default title of a <!collapse> section.
+const defaultCollapseTitle = "Show more"
+
+var (
+	blockBreakRe = regexp.MustCompile(`<!break>\s*$`)
+	directiveRe  = regexp.MustCompile(`<!(hide|show|collapse)(?:\s+title="([^"]*)")?>\s*$`)
+)
+
+func isBlockBreak(line string) bool {
+	return blockBreakRe.MatchString(line)
+}
+
+func isDirective(line string) bool {
+	return directiveRe.MatchString(line)
+}
+
+// parseDirective returns the name and title of the directive on the line.
+func parseDirective(line string) (string, string) {
+	m := directiveRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", ""
+	}
+	return m[1], m[2]
+}
+
+// ParseError describes an invalid construct in a client source file.
+type ParseError struct {
+	// Path to the source file.
+	Path string
+	// Line number, starting at 1, or zero if not specific to a line.
+	Line int
+	// The offending line.
+	Text string
+	// Reason the line could not be parsed.
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Path)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Reason)
+	if t := strings.TrimSpace(e.Text); t != "" {
+		fmt.Fprintf(&b, "\n\t%s", t)
+	}
+	return b.String()
+}
+
+// ParseSource splits the source of a file in the named language into blocks.
+// The source is returned with the trailing newline removed.
+func (p *Parser) ParseSource(name string, r io.Reader) ([]*Block, string, error) {
+	l, ok := p.languages().Get(name)
+	if !ok || l.Syntax == nil {
+		return nil, "", &ParseError{
+			Reason: fmt.Sprintf("language %q not currently supported", name),
+		}
+	}
+	return parseReader(newLexer(l.Syntax), r)
+}
+
+func parseReader(lx *lexer, r io.Reader) ([]*Block, string, error) {
+	var (
+		lineNum      int
+		block        = &Block{StartLine: 1, EndLine: 1}
+		blocks       = []*Block{block}
+		endMultiLine = false
+		endBlock     = false
+		lines        []string
+
+		// Current state set by the hide, show and collapse directives.
+		hidden   bool
+		collapse string
+	)
+
+	// Starts a new block which inherits the current directive state.
+	newBlock := func(t BlockType) {
+		block = &Block{
+			Type:      t,
+			StartLine: lineNum,
+			EndLine:   lineNum,
+			Hidden:    hidden,
+			Collapse:  collapse,
+		}
+		blocks = append(blocks, block)
+	}
+
+	// Read each line, keeping track of comment and code lines.
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		lineNum++
+		line := sc.Text()
+
+		lines = append(lines, line)
+
+		if endMultiLine || endBlock || block.Type == BreakBlock {
+			newBlock(EmptyBlock)
+		}
+
+		endMultiLine = false
+		endBlock = false
+		lineType := lx.Next(line)
+
+		switch lineType {
+		// Does not differentiate a boundary, the line is appended to the
+		// current block below.
+		case EmptyLine:
+
+		case BreakLine:
+			switch block.Type {
+			// Only valid as a boundary from a single line comment block.
+			case SingleLineCommentBlock:
+				newBlock(BreakBlock)
+
+			case EmptyBlock, CodeBlock, MultiLineCommentBlock:
+				return nil, "", &ParseError{
+					Line:   lineNum,
+					Text:   line,
+					Reason: "<!break> is only valid following a single line comment",
+				}
+			}
+
+		case DirectiveLine:
+			switch name, title := parseDirective(line); name {
+			case "hide":
+				hidden = true
+				collapse = ""
+			case "collapse":
+				hidden = false
+				collapse = title
+				if collapse == "" {
+					collapse = defaultCollapseTitle
+				}
+			case "show":
+				hidden = false
+				collapse = ""
+			}
+
+			// The directive line itself is not rendered. A block with no
+			// content yet, including blank lines, takes on the new state,
+			// otherwise the following line starts a new block.
+			if block.Type == EmptyBlock {
+				block.Lines = nil
+				block.StartLine = lineNum + 1
+				block.EndLine = lineNum + 1
+				block.Hidden = hidden
+				block.Collapse = collapse
+			} else {
+				endBlock = true
+			}
+			continue
+
+		case NormalLine:
+			switch block.Type {
+			// If not already a comment block, a normal line implies code.
+			case EmptyBlock:
+				block.Type = CodeBlock
+
+			// Boundary from single line comment -> code
+			case SingleLineCommentBlock:
+				newBlock(CodeBlock)
+
+			// Normal line is part of the code block.
+			case CodeBlock:
+
+			// Indicates code that is part of a multi-line comment.
+			case MultiLineCommentBlock:
+			}
+
+		case SingleCommentLine:
+			switch block.Type {
+			case EmptyBlock:
+				block.Type = SingleLineCommentBlock
+
+			// Boundary from code -> comment.
+			case CodeBlock:
+				newBlock(SingleLineCommentBlock)
+
+			// Single line comment within a multi line is just a normal line.
+			case MultiLineCommentBlock:
+			case SingleLineCommentBlock:
+			}
+
+		case OpenMultiCommentLine:
+			switch block.Type {
+			case EmptyBlock:
+				block.Type = MultiLineCommentBlock
+
+			// Boundary code -> multi-line
+			case CodeBlock:
+				newBlock(MultiLineCommentBlock)
+
+			// An opening comment or single comment in an existing multi-line
+			// comment has no effect.
+			case MultiLineCommentBlock:
+			case SingleLineCommentBlock:
+			}
+
+		case CloseMultiCommentLine:
+			switch block.Type {
+			// Only valid block type where this line is relevant.
+			case MultiLineCommentBlock:
+				endMultiLine = true
+
+			case EmptyBlock, CodeBlock, SingleLineCommentBlock:
+				return nil, "", &ParseError{
+					Line:   lineNum,
+					Text:   line,
+					Reason: "unbalanced close of multi-line comment",
+				}
+			}
+		}
+
+		// Finally append the line and set the end line of the block.
+		block.Lines = append(block.Lines, line)
+		block.EndLine = lineNum
+	}
+	if err := sc.Err(); err != nil {
+		return nil, "", err
+	}
+
+	return blocks, strings.Join(lines, "\n"), nil
+}
+
+// fileLanguage returns the language of a file based on its extension. The
+// client language takes precedence, followed by the first language in the
+// registry with a main file of the same extension.
+func (p *Parser) fileLanguage(name, clientLang string) string {
+	ext := filepath.Ext(name)
+	if ext == "" {
+		return ""
+	}
+	langs := p.languages()
+	if l, ok := langs.Get(clientLang); ok && filepath.Ext(l.Main) == ext {
+		return l.Name
+	}
+	for _, l := range langs.All() {
+		if l.Main != "" && filepath.Ext(l.Main) == ext {
+			return l.Name
+		}
+	}
+	return ""
+}
+
+// isLocal returns true if the relative path name does not escape the
+// directory it is relative to.
+func isLocal(name string) bool {
+	if filepath.IsAbs(name) {
+		return false
+	}
+	name = filepath.Clean(name)
+	return name != ".." && !strings.HasPrefix(name, ".."+string(filepath.Separator))
+}
+
+// readClientFile reads and parses an additional source file of a client.
+func (p *Parser) readClientFile(path, name, clientLang string) (*File, error) {
+	filePath := filepath.Join(path, name)
+	if !isLocal(name) {
+		return nil, &ParseError{
+			Path:   filePath,
+			Reason: "file must be within the client directory",
+		}
+	}
+
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &ParseError{
+				Path:   filePath,
+				Reason: "file listed in meta.yaml does not exist",
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	x := File{
+		Name:     filepath.ToSlash(name),
+		Language: p.fileLanguage(name, clientLang),
+	}
+
+	// Files in unsupported languages are shown as is.
+	if x.Language == "" {
+		var lines []string
+		sc := bufio.NewScanner(bytes.NewReader(b))
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		x.Source = strings.Join(lines, "\n")
+		x.Blocks = []*Block{{
+			Type:      CodeBlock,
+			Lines:     lines,
+			StartLine: 1,
+			EndLine:   len(lines),
+		}}
+		return &x, nil
+	}
+
+	blocks, source, err := p.ParseSource(x.Language, bytes.NewReader(b))
+	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			perr.Path = filePath
+			return nil, perr
+		}
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	x.Blocks = blocks
+	x.Source = source
+
+	return &x, nil
+}
+
+// ParseClient parses the client directory at path, along with the additional
+// files, relative to the directory, shown alongside the main file.
+func (p *Parser) ParseClient(path, name string, files []string) (*Client, error) {
+	x := Client{
+		Name: name,
+		Path: path,
+	}
+	langs := p.languages()
+	name = strings.ToLower(name)
+
+	// Default to script if not known or not supported for docs.
+	l, ok := langs.Get(name)
+	if !ok || l.Main == "" {
+		l, ok = langs.Get(lang.Shell)
+	}
+
+	// Determine main file name.
+	if !ok || l.Main == "" {
+		return nil, &ParseError{
+			Path:   path,
+			Reason: fmt.Sprintf("language %q not yet supported", name),
+		}
+	}
+	mainFile := l.Main
+
+	// Ensure main file exists.
+	mainPath := filepath.Join(path, mainFile)
+	f, err := os.Open(mainPath)
+	if err != nil {
+		return nil, fmt.Errorf("open main file: %w", err)
+	}
+	defer f.Close()
+
+	blocks, source, err := p.ParseSource(l.Name, f)
+	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			perr.Path = mainPath
+			return nil, perr
+		}
+		return nil, fmt.Errorf("%s: %w", mainPath, err)
+	}
+	x.Language = l.Name
+	x.MainFile = mainFile
+	x.Blocks = blocks
+	x.Source = source
+
+	var errs MultiErr
+	for _, n := range files {
+		f, err := p.readClientFile(path, n, l.Name)
+		if err != nil {
+			errs.Append(err)
+			continue
+		}
+		x.Files = append(x.Files, f)
+	}
+	if !errs.Empty() {
+		return nil, &errs
+	}
+
+	return &x, nil
+}
+
+// ParseExample parses the example directory at path, including its clients.
+// The requires and related examples are not checked.
+func (p *Parser) ParseExample(path string) (*Example, error) {
+	return p.readExampleDir(path, filepath.Base(path))
+}
+
+// ReadExampleMeta reads the meta.yaml file of the example directory at path,
+// if any, without parsing its clients or validating the metadata.
+func ReadExampleMeta(path string) (*Example, error) {
+	return readExampleMeta(path, filepath.Base(path))
+}
+
+func readExampleMeta(path, name string) (*Example, error) {
+	x := Example{
+		Name:    name,
+		Path:    path,
+		Title:   strings.Title(name),
+		Clients: make(map[string]*Client),
+	}
+
+	meta, err := fs.ReadFile(os.DirFS(path), "meta.yaml")
+	if err == nil {
+		if err := yaml.Unmarshal(meta, &x); err != nil {
+			return nil, fmt.Errorf("%s: parse yaml: %w", filepath.Join(path, "meta.yaml"), err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: read meta: %w", path, err)
+	}
+
+	return &x, nil
+}
+
+func (p *Parser) readExampleDir(path, name string) (*Example, error) {
+	x, err := readExampleMeta(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := fs.ReadDir(os.DirFS(path), ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: read dir: %w", path, err)
+	}
+
+	var errs MultiErr
+	errs.Append(x.validateMeta())
+
+	clients := make(map[string]*Client)
+	for _, e := range dirs {
+		if !e.IsDir() {
+			continue
+		}
+
+		name := e.Name()
+		path := filepath.Join(path, name)
+		im, err := p.ParseClient(path, name, x.Files[name])
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				p.logf("%s: no main file. skipping...", path)
+				continue
+			}
+			var (
+				perr *ParseError
+				merr *MultiErr
+			)
+			if !errors.As(err, &perr) && !errors.As(err, &merr) {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+			errs.Append(err)
+			continue
+		}
+		clients[name] = im
+	}
+
+	if !errs.Empty() {
+		return nil, &errs
+	}
+
+	for _, i := range clients {
+		x.Clients[i.Name] = i
+	}
+
+	return x, nil
+}
+
+func (p *Parser) readCategoryDir(path, name string) (*Category, error) {
+	c := Category{
+		Name:  name,
+		Path:  path,
+		Title: strings.Title(name),
+	}
+
+	type categoryMeta struct {
+		Title       string
+		Description string
+		Examples    []string
+	}
+
+	var cm categoryMeta
+
+	// Read meta file.
+	meta, err := fs.ReadFile(os.DirFS(path), "meta.yaml")
+	if err == nil {
+		if err := yaml.Unmarshal(meta, &cm); err != nil {
+			return nil, fmt.Errorf("%s: parse yaml: %w", filepath.Join(path, "meta.yaml"), err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: read meta: %w", path, err)
+	}
+
+	if cm.Title != "" {
+		c.Title = cm.Title
+	}
+	c.Description = cm.Description
+
+	dirs, err := fs.ReadDir(os.DirFS(path), ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: read dir: %w", path, err)
+	}
+
+	// Errors are collected across all examples so they can be reported
+	// together rather than one at a time.
+	var errs MultiErr
+	exs := make(map[string]*Example)
+	for _, e := range dirs {
+		if !e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		path := filepath.Join(path, name)
+		ex, err := p.readExampleDir(path, name)
+		if err != nil {
+			errs.Append(err)
+			continue
+		}
+
+		if len(ex.Clients) > 0 {
+			exs[name] = ex
+		}
+	}
+
+	if !errs.Empty() {
+		return nil, &errs
+	}
+
+	// Append ordered examples first.
+	for _, name := range cm.Examples {
+		if _, ok := exs[name]; !ok {
+			continue
+		}
+		c.Examples = append(c.Examples, exs[name])
+		delete(exs, name)
+	}
+
+	// Append the reminder to the end, sorted by title.
+	var rest []*Example
+	for _, e := range exs {
+		rest = append(rest, e)
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		if rest[i].Title != rest[j].Title {
+			return rest[i].Title < rest[j].Title
+		}
+		return rest[i].Name < rest[j].Name
+	})
+	c.Examples = append(c.Examples, rest...)
+
+	return &c, nil
+}
+
+// Parse parses the examples tree at path. Errors are collected across all
+// examples and returned together as a *MultiErr.
+func (p *Parser) Parse(path string) (*Root, error) {
+	r := Root{
+		Path: path,
+	}
+
+	type rootMeta struct {
+		Categories []string
+	}
+	var rm rootMeta
+
+	// Read meta file.
+	meta, err := fs.ReadFile(os.DirFS(path), "meta.yaml")
+	if err == nil {
+		if err := yaml.Unmarshal(meta, &rm); err != nil {
+			return nil, fmt.Errorf("%s: parse yaml: %w", filepath.Join(path, "meta.yaml"), err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: read meta: %w", path, err)
+	}
+
+	// Root will read the categories.
+	dirs, err := fs.ReadDir(os.DirFS(path), ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: read dir: %w", path, err)
+	}
+
+	var errs MultiErr
+	cats := make(map[string]*Category)
+	for _, e := range dirs {
+		if !e.IsDir() {
+			continue
+		}
+
+		name := e.Name()
+		path := filepath.Join(path, name)
+		c, err := p.readCategoryDir(path, name)
+		if err != nil {
+			errs.Append(err)
+			continue
+		}
+
+		if len(c.Examples) > 0 {
+			cats[name] = c
+		}
+	}
+
+	if !errs.Empty() {
+		return nil, &errs
+	}
+
+	// Append ordered categories first.
+	for _, name := range rm.Categories {
+		if _, ok := cats[name]; !ok {
+			continue
+		}
+
+		r.Categories = append(r.Categories, cats[name])
+		delete(cats, name)
+	}
+
+	// Append the reminder to the end, sorted by title.
+	var rest []*Category
+	for _, c := range cats {
+		rest = append(rest, c)
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		if rest[i].Title != rest[j].Title {
+			return rest[i].Title < rest[j].Title
+		}
+		return rest[i].Name < rest[j].Name
+	})
+	r.Categories = append(r.Categories, rest...)
+
+	if err := r.validateRefs(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,519 @@
+package examples
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+func checkEqual[T comparable](t *testing.T, a, b T) {
+	t.Helper()
+	if a != b {
+		t.Error("not equal")
+	}
+}
+
+// parseLineType classifies a single line in isolation.
+func parseLineType(name, line string) LineType {
+	l, ok := lang.Default.Get(name)
+	if !ok || l.Syntax == nil {
+		panic(fmt.Sprintf("%q not currently supported", name))
+	}
+	return newLexer(l.Syntax).Next(line)
+}
+
+func parseSource(name string, r io.Reader) ([]*Block, string, error) {
+	var p Parser
+	return p.ParseSource(name, r)
+}
+
+func logBlocks(t *testing.T, blocks []*Block) {
+	b, _ := yaml.Marshal(blocks)
+	t.Log(string(b))
+}
+
+func TestParseLineType(t *testing.T) {
+	// C-style
+	checkEqual(t, parseLineType(lang.Go, `  /* hello`), OpenMultiCommentLine)
+	checkEqual(t, parseLineType(lang.Go, `yep */`), CloseMultiCommentLine)
+	checkEqual(t, parseLineType(lang.Go, `		// ba`), SingleCommentLine)
+	checkEqual(t, parseLineType(lang.Go, ` /* meh  */ `), NormalLine)
+	checkEqual(t, parseLineType(lang.Go, ` Foo int		// int`), NormalLine)
+	checkEqual(t, parseLineType(lang.Go, ` 1 / 2 `), NormalLine)
+	checkEqual(t, parseLineType(lang.Go, `			`), EmptyLine)
+
+	// Whitespace-sensitive
+	checkEqual(t, parseLineType(lang.Python, `		#  ba`), SingleCommentLine)
+	checkEqual(t, parseLineType(lang.Python, `##ba`), SingleCommentLine)
+}
+
+func TestParseReader(t *testing.T) {
+	goCode := `/*
+Package foo provides utilies for interacting with JetStream.
+
+*/
+package foo
+
+// Read stream..
+func ReadStream(js nats.JetStreamContext, name string) ([]*nats.Msg, error) {
+  ...
+}
+
+`
+	blocks, source, err := parseSource(lang.Go, bytes.NewBuffer([]byte(goCode)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedGoSource := strings.TrimSuffix(goCode, "\n") // Remove the last empty line
+	if diff := cmp.Diff(expectedGoSource, source); diff != "" {
+		t.Error(diff)
+	}
+	checkEqual(t, len(blocks), 4)
+
+	// The lines of each block are those of the source it spans.
+	lines := strings.Split(expectedGoSource, "\n")
+	for _, b := range blocks {
+		if diff := cmp.Diff(lines[b.StartLine-1:b.EndLine], b.Lines); diff != "" {
+			t.Errorf("lines %d-%d: %s", b.StartLine, b.EndLine, diff)
+		}
+	}
+
+	pythonCode := `# Package foo
+import csv
+
+with open('somefile.txt') as f:
+	# Initialize a new CSV reader
+
+	cr := csv.reader(f)
+
+	# Read
+	# all
+	# the
+
+	# lines
+	lines := tuple(cr)
+
+`
+
+	blocks, source, err = parseSource(lang.Python, bytes.NewBuffer([]byte(pythonCode)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPythonCode := strings.TrimSuffix(pythonCode, "\n") // Remove the last empty line
+	if diff := cmp.Diff(expectedPythonCode, source); diff != "" {
+		t.Error(diff)
+	}
+	checkEqual(t, len(blocks), 6)
+}
+
+func TestParseLineTypeStrings(t *testing.T) {
+	// Comment delimiters within string literals.
+	checkEqual(t, parseLineType(lang.Go, `	url := "nats://localhost:4222"`), NormalLine)
+	checkEqual(t, parseLineType(lang.Go, `	glob := "events.*/"`), NormalLine)
+	checkEqual(t, parseLineType(lang.Go, "	raw := `/* not a comment`"), NormalLine)
+	checkEqual(t, parseLineType(lang.Rust, `	let s = r#"a "*/" b"#;`), NormalLine)
+	checkEqual(t, parseLineType(lang.Deno, `	const s = '/* nope';`), NormalLine)
+	checkEqual(t, parseLineType(lang.Node, "	const s = `// nope`;"), NormalLine)
+	checkEqual(t, parseLineType(lang.Python, `	print("# not a comment")`), NormalLine)
+	checkEqual(t, parseLineType(lang.Ruby, `	puts '#{x}'`), NormalLine)
+	checkEqual(t, parseLineType(lang.CLI, `echo "$#" ${#arr[@]}`), NormalLine)
+
+	// Rust lifetimes are not char literals.
+	checkEqual(t, parseLineType(lang.Rust, `	// fn foo<'a>(x: &'a str)`), SingleCommentLine)
+}
+
+func TestParseReaderLexer(t *testing.T) {
+	// Trailing multi-line comment is part of the code.
+	goCode := `// Foo..
+func Foo() int {/*
+	a := 1
+*/
+	return 2
+}`
+	blocks, _, err := parseSource(lang.Go, bytes.NewBufferString(goCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(blocks), 2)
+	checkEqual(t, blocks[1].Type, CodeBlock)
+
+	// Nested block comments.
+	rustCode := `/* outer
+/* inner */
+still a comment
+*/
+fn main() {}`
+	blocks, _, err = parseSource(lang.Rust, bytes.NewBufferString(rustCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(blocks), 2)
+	checkEqual(t, blocks[0].Type, MultiLineCommentBlock)
+	checkEqual(t, blocks[0].EndLine, 4)
+
+	// Comment lines within a multi-line string and a heredoc are code.
+	pythonCode := `x = """
+# not a comment
+"""
+# A comment`
+	blocks, _, err = parseSource(lang.Python, bytes.NewBufferString(pythonCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(blocks), 2)
+	checkEqual(t, blocks[0].Type, CodeBlock)
+	checkEqual(t, blocks[1].StartLine, 4)
+
+	shellCode := `cat <<- EOF > server.conf
+	# not a comment
+	port: 4222
+EOF
+
+# A comment`
+	blocks, _, err = parseSource(lang.Shell, bytes.NewBufferString(shellCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(blocks), 2)
+	checkEqual(t, blocks[0].Type, CodeBlock)
+}
+
+func TestParseReaderErrors(t *testing.T) {
+	_, _, err := parseSource(lang.Go, bytes.NewBufferString("// Package main..\n// <!break>\nfunc main() {}\n*/"))
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	checkEqual(t, perr.Line, 4)
+	checkEqual(t, perr.Text, "*/")
+
+	_, _, err = parseSource(lang.Go, bytes.NewBufferString("func main() {}\n// <!break>"))
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	checkEqual(t, perr.Line, 2)
+
+	_, _, err = parseSource("cobol", bytes.NewBufferString(""))
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestParseReaderDocComments(t *testing.T) {
+	pythonCode := `import nats
+
+async def main():
+    """
+    Connect to the server.
+    """
+    nc = await nats.connect(servers=[
+        "nats://localhost:4222",
+    ])
+    print(textwrap.dedent(
+        """
+        Not a docstring.
+        """))`
+	blocks, _, err := parseSource(lang.Python, bytes.NewBufferString(pythonCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(blocks), 3)
+	checkEqual(t, blocks[1].Type, MultiLineCommentBlock)
+	checkEqual(t, blocks[1].StartLine, 4)
+	checkEqual(t, blocks[1].EndLine, 6)
+	checkEqual(t, blocks[2].Type, CodeBlock)
+
+	rubyCode := `=begin
+Connect to the server.
+=end
+nc = NATS.connect`
+	blocks, _, err = parseSource(lang.Ruby, bytes.NewBufferString(rubyCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(blocks), 2)
+	checkEqual(t, blocks[0].Type, MultiLineCommentBlock)
+
+	elixirCode := `defmodule Example do
+  @moduledoc """
+  Connect to the server.
+  """
+end`
+	blocks, _, err = parseSource(lang.Elixir, bytes.NewBufferString(elixirCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(blocks), 3)
+	checkEqual(t, blocks[1].Type, MultiLineCommentBlock)
+
+	// Rust doc comments end with the line, like line comments.
+	checkEqual(t, parseLineType(lang.Rust, "	/// Connect to the server."), SingleCommentLine)
+	checkEqual(t, parseLineType(lang.Rust, "//! Examples of the client."), SingleCommentLine)
+	checkEqual(t, parseLineType(lang.Rust, "let n = 1; /// not a doc comment"), NormalLine)
+
+	rustCode := `//! Examples of the client.
+/// Connect to the server.
+fn main() {
+    let n = 1; /// not a doc comment
+}`
+	blocks, _, err = parseSource(lang.Rust, bytes.NewBufferString(rustCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(blocks), 2)
+	checkEqual(t, blocks[0].Type, SingleLineCommentBlock)
+	checkEqual(t, blocks[0].EndLine, 2)
+	checkEqual(t, blocks[1].Type, CodeBlock)
+}
+
+func TestParseClientDocComments(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"ruby/main.rb": `require 'nats/client'
+
+=begin
+Connect to the server.
+=end
+nc = NATS.connect`,
+	})
+
+	var p Parser
+	c, err := p.ParseClient(filepath.Join(dir, "ruby"), lang.Ruby, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, c.Language, lang.Ruby)
+	checkEqual(t, c.MainFile, "main.rb")
+	checkEqual(t, len(c.Blocks), 3)
+	checkEqual(t, c.Blocks[1].Type, MultiLineCommentBlock)
+	checkEqual(t, c.Blocks[1].StartLine, 3)
+	checkEqual(t, c.Blocks[1].EndLine, 5)
+	checkEqual(t, c.Blocks[2].Type, CodeBlock)
+}
+
+func TestParseReaderDirectives(t *testing.T) {
+	code := `// <!hide>
+package main
+
+import "fmt"
+
+// <!show>
+// Print a greeting.
+func main() {
+	// <!collapse title="Setup">
+	name := "world"
+	// <!show>
+	fmt.Println("hello", name)
+}`
+	blocks, source, err := parseSource(lang.Go, bytes.NewBufferString(code))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, source, code)
+	checkEqual(t, len(blocks), 5)
+
+	checkEqual(t, blocks[0].Type, CodeBlock)
+	checkEqual(t, blocks[0].Hidden, true)
+	checkEqual(t, blocks[0].StartLine, 2)
+	checkEqual(t, blocks[0].EndLine, 5)
+
+	checkEqual(t, blocks[1].Type, SingleLineCommentBlock)
+	checkEqual(t, blocks[1].Hidden, false)
+	checkEqual(t, blocks[1].StartLine, 7)
+
+	checkEqual(t, blocks[2].Type, CodeBlock)
+	checkEqual(t, blocks[2].EndLine, 8)
+
+	checkEqual(t, blocks[3].Type, CodeBlock)
+	checkEqual(t, blocks[3].Collapse, "Setup")
+	checkEqual(t, len(blocks[3].Lines), 1)
+
+	checkEqual(t, blocks[4].Type, CodeBlock)
+	checkEqual(t, blocks[4].Collapse, "")
+	checkEqual(t, blocks[4].StartLine, 12)
+}
+
+func TestFileLanguage(t *testing.T) {
+	var p Parser
+	checkEqual(t, p.fileLanguage("service/main.go", lang.CLI), lang.Go)
+	checkEqual(t, p.fileLanguage("types.pb.go", lang.Go), lang.Go)
+	checkEqual(t, p.fileLanguage("util.js", lang.Node), lang.Node)
+	checkEqual(t, p.fileLanguage("util.js", lang.Go), lang.Deno)
+	checkEqual(t, p.fileLanguage("types.proto", lang.Go), "")
+	checkEqual(t, p.fileLanguage("Makefile", lang.Go), "")
+}
+
+func TestIsLocal(t *testing.T) {
+	checkEqual(t, isLocal("service/main.go"), true)
+	checkEqual(t, isLocal("..config.yaml"), true)
+	checkEqual(t, isLocal("a/../b.go"), true)
+	checkEqual(t, isLocal(".."), false)
+	checkEqual(t, isLocal("../main.go"), false)
+	checkEqual(t, isLocal("a/../../main.go"), false)
+	checkEqual(t, isLocal("/etc/passwd"), false)
+}
+
+// writeTree writes the files, keyed by their path relative to dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLintExamples(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"meta.yaml":                        "categories: [messaging, missing]\n",
+		"messaging/meta.yaml":              "title: Messaging\nexampels: [pub-sub]\n",
+		"messaging/pub-sub/meta.yaml":      "title: Pub-Sub\n",
+		"messaging/pub-sub/go/main.go":     "package main\n",
+		"messaging/pub-sub/go/output.txt":  "",
+		"messaging/pub-sub/go/output.cast": "",
+		"messaging/pub-sub/cobol/main.sh":  "echo\n",
+		"messaging/pub-sub/python/x.py":    "",
+	}
+	writeTree(t, dir, files)
+
+	var p Parser
+	issues, err := p.Lint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := make(map[string]int)
+	for _, i := range issues {
+		rules[i.Rule]++
+	}
+	checkEqual(t, rules[LintOrdering], 1)
+	checkEqual(t, rules[LintMetaKey], 1)
+	checkEqual(t, rules[LintDescription], 1)
+	checkEqual(t, rules[LintLanguage], 1)
+	checkEqual(t, rules[LintMainFile], 1)
+	checkEqual(t, rules[LintOutput], 2)
+	checkEqual(t, len(issues), 7)
+
+	for _, i := range issues {
+		if i.Rule == LintMetaKey {
+			checkEqual(t, i.Message, `unknown key "exampels", did you mean "examples"?`)
+		}
+	}
+}
+
+func TestSortedClients(t *testing.T) {
+	e := Example{
+		Clients: map[string]*Client{
+			"shell":  {Name: "shell"},
+			"rust":   {Name: "rust"},
+			"go":     {Name: "go"},
+			"cli":    {Name: "cli"},
+			"dotnet": {Name: "dotnet"},
+		},
+	}
+	var names []string
+	for _, c := range e.SortedClients(nil) {
+		names = append(names, c.Name)
+	}
+	checkEqual(t, strings.Join(names, ","), "cli,go,rust,dotnet,shell")
+}
+
+func TestParseExampleMeta(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"kv/intro/meta.yaml": `
+level: beginner
+tags: [key-value]
+features: [JetStream, KV]
+min_server_version: 2.10.0
+requires: [kv/watch]
+added: 2023-01-31
+`,
+		"kv/intro/go/main.go": "package main\n",
+		"kv/watch/meta.yaml":  "related: [kv/intro]\n",
+		"kv/watch/go/main.go": "package main\n",
+	})
+
+	var p Parser
+	root, err := p.Parse(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := root.Example("kv/intro")
+	if e == nil {
+		t.Fatal("expected kv/intro")
+	}
+	checkEqual(t, e.Level, LevelBeginner)
+	checkEqual(t, e.Tags[0], "key-value")
+	checkEqual(t, e.Features[1], "KV")
+	checkEqual(t, root.Example(e.Requires[0]).Name, "watch")
+	checkEqual(t, e.SupportsServer("2.9.25"), false)
+	checkEqual(t, e.SupportsServer("2.10.0"), true)
+	checkEqual(t, e.SupportsServer("v2.11"), true)
+	checkEqual(t, e.SupportsServer("latest"), true)
+	checkEqual(t, e.Added, "2023-01-31")
+
+	writeTree(t, dir, map[string]string{
+		"kv/watch/meta.yaml": `
+level: expert
+tags: [Key Value]
+min_server_version: two
+added: 31/01/2023
+related: [kv/missing]
+`,
+	})
+	_, err = p.Parse(dir)
+	var errs *MultiErr
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected errors, got %v", err)
+	}
+	checkEqual(t, len(*errs), 4)
+
+	// References are checked once the rest of the metadata is valid.
+	writeTree(t, dir, map[string]string{
+		"kv/watch/meta.yaml": "related: [kv/missing]\n",
+	})
+	_, err = p.Parse(dir)
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected errors, got %v", err)
+	}
+	checkEqual(t, len(*errs), 1)
+}
+
+func TestReadExampleMeta(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"meta.yaml":  "min_server_version: 2.10.0\n",
+		"go/main.go": "package main\n// <!break>",
+	})
+
+	var p Parser
+	if _, err := p.ParseExample(dir); err == nil {
+		t.Fatal("expected the client to fail to parse")
+	}
+
+	// The metadata is read without parsing the clients.
+	e, err := ReadExampleMeta(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, e.MinServerVersion, "2.10.0")
+	checkEqual(t, e.SupportsServer("2.9.0"), false)
+	checkEqual(t, len(e.Clients), 0)
+}
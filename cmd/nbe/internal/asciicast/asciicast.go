@@ -0,0 +1,115 @@
+// Package asciicast records the output of a program run under a
+// pseudo-terminal in the asciicast v2 format played by asciinema.
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Title     string            `json:"title,omitempty"`
+}
+
+// Event is output written to the terminal, at the time in seconds since the
+// start of the recording.
+type Event struct {
+	Time float64
+	Data string
+}
+
+// Cast is a recording of the output of a terminal.
+type Cast struct {
+	Header Header
+	Events []Event
+}
+
+// Encode writes the recording as asciicast v2, the header followed by a line
+// per event.
+func (c *Cast) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(&c.Header); err != nil {
+		return err
+	}
+	for _, e := range c.Events {
+		// Microseconds, as recorded by asciinema.
+		t := math.Round(e.Time*1e6) / 1e6
+		if err := enc.Encode([]any{t, "o", e.Data}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Decode reads a recording in the asciicast v2 format. Events other than
+// output are skipped.
+func Decode(r io.Reader) (*Cast, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 16*1024*1024)
+
+	var c Cast
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("missing header")
+	}
+	if err := json.Unmarshal(sc.Bytes(), &c.Header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	if c.Header.Version != 2 {
+		return nil, fmt.Errorf("unsupported version %d", c.Header.Version)
+	}
+
+	for n := 2; sc.Scan(); n++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var (
+			fields []json.RawMessage
+			e      Event
+			kind   string
+		)
+		err := json.Unmarshal(sc.Bytes(), &fields)
+		if err == nil && len(fields) != 3 {
+			err = fmt.Errorf("expected 3 fields, got %d", len(fields))
+		}
+		if err == nil {
+			err = json.Unmarshal(fields[0], &e.Time)
+		}
+		if err == nil {
+			err = json.Unmarshal(fields[1], &kind)
+		}
+		if err == nil && kind == "o" {
+			err = json.Unmarshal(fields[2], &e.Data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if kind == "o" {
+			c.Events = append(c.Events, e)
+		}
+	}
+	return &c, sc.Err()
+}
+
+// Text returns the output of the recording, as printed by asciinema cat.
+func (c *Cast) Text() string {
+	var b strings.Builder
+	for _, e := range c.Events {
+		b.WriteString(e.Data)
+	}
+	return b.String()
+}
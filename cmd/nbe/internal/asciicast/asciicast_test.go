@@ -0,0 +1,115 @@
+package asciicast
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecord(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+
+	var echo bytes.Buffer
+	r := Recorder{
+		Width:  100,
+		Height: 30,
+		Title:  "test",
+		Echo:   &echo,
+	}
+	c, err := r.Record(func(tty *os.File) error {
+		cmd := exec.Command("sh", "-c", `printf 'one\n'; sleep 0.1; printf 'h\303\251\n'; [ -t 1 ] && printf tty`)
+		cmd.Stdout = tty
+		cmd.Stderr = tty
+		return cmd.Run()
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "open pty") {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+
+	// The terminal translates newlines to CRLF.
+	if diff := cmp.Diff("one\r\nhé\r\ntty", c.Text()); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(c.Text(), echo.String()); diff != "" {
+		t.Error(diff)
+	}
+	if len(c.Events) < 2 {
+		t.Fatalf("expected an event per write, got %d", len(c.Events))
+	}
+	for i := 1; i < len(c.Events); i++ {
+		if c.Events[i].Time < c.Events[i-1].Time {
+			t.Errorf("event %d is before the previous one", i)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"version":2,"width":100,"height":30,`) {
+		t.Errorf("unexpected header: %s", buf.String())
+	}
+	d, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(c.Header, d.Header); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(c.Text(), d.Text()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell")
+	}
+
+	var r Recorder
+	c, err := r.Record(func(tty *os.File) error {
+		cmd := exec.Command("sh", "-c", `echo failed; exit 1`)
+		cmd.Stdout = tty
+		cmd.Stderr = tty
+		return cmd.Run()
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "open pty") {
+		t.Skip(err)
+	}
+	if c == nil {
+		t.Fatal("expected the recording of the output so far")
+	}
+	if diff := cmp.Diff("failed\r\n", c.Text()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	input := `{"version": 2, "width": 174, "height": 79, "timestamp": 1678973691, "env": {"SHELL": "/bin/bash", "TERM": "xterm-color"}, "title": "NATS by Example: messaging/pub-sub/go"}
+[1.150838, "o", "subscribed after a publish...\r\n"]
+[1.2, "i", "q"]
+[1.151016, "o", "msg data: \"hello\"\r\n"]
+`
+	c, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("subscribed after a publish...\r\nmsg data: \"hello\"\r\n", c.Text()); diff != "" {
+		t.Error(diff)
+	}
+	if c.Header.Width != 174 || c.Header.Title != "NATS by Example: messaging/pub-sub/go" {
+		t.Errorf("unexpected header: %+v", c.Header)
+	}
+}
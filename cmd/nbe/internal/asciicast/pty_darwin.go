@@ -0,0 +1,42 @@
+package asciicast
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// openPTY opens a new pseudo-terminal, returning its master and slave.
+func openPTY() (master, tty *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var name [128]byte
+	for _, req := range []struct {
+		op  uintptr
+		arg unsafe.Pointer
+	}{
+		{syscall.TIOCPTYGRANT, nil},
+		{syscall.TIOCPTYUNLK, nil},
+		{syscall.TIOCPTYGNAME, unsafe.Pointer(&name)},
+	} {
+		if err := ioctl(master, req.op, req.arg); err != nil {
+			master.Close()
+			return nil, nil, err
+		}
+	}
+
+	if i := bytes.IndexByte(name[:], 0); i >= 0 {
+		tty, err = os.OpenFile(string(name[:i]), os.O_RDWR|syscall.O_NOCTTY, 0)
+	} else {
+		err = syscall.EINVAL
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, tty, nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package asciicast
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func openPTY() (master, tty *os.File, err error) {
+	return nil, nil, fmt.Errorf("pseudo-terminals are not supported on %s", runtime.GOOS)
+}
+
+func setSize(tty *os.File, width, height int) error {
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build linux || darwin
+
+package asciicast
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// setSize sets the size of the terminal in columns and rows.
+func setSize(tty *os.File, width, height int) error {
+	ws := struct {
+		rows, cols, x, y uint16
+	}{
+		rows: uint16(height),
+		cols: uint16(width),
+	}
+	return ioctl(tty, syscall.TIOCSWINSZ, unsafe.Pointer(&ws))
+}
+
+func ioctl(f *os.File, op uintptr, arg unsafe.Pointer) error {
+	sc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = sc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, op, uintptr(arg))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
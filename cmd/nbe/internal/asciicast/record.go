@@ -0,0 +1,143 @@
+package asciicast
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// Recorder runs programs under a pseudo-terminal and records their output.
+type Recorder struct {
+	// Size of the terminal in columns and rows. Defaults to 80 by 24.
+	Width  int
+	Height int
+	// Title of the recording. Optional.
+	Title string
+	// Echo is written the output as it is recorded, e.g. os.Stdout to show
+	// it on the console as well. Write errors are ignored. Optional.
+	Echo io.Writer
+}
+
+// Record calls run with the terminal to record, e.g. to use as the stdout
+// and stderr of a command, and returns the recording once run returns and
+// all output written to the terminal is read. If run fails, the recording
+// of the output so far is returned along with the error.
+func (r *Recorder) Record(run func(tty *os.File) error) (*Cast, error) {
+	width, height := r.Width, r.Height
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+
+	master, tty, err := openPTY()
+	if err != nil {
+		return nil, fmt.Errorf("open pty: %w", err)
+	}
+	defer master.Close()
+	if err := setSize(tty, width, height); err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("set pty size: %w", err)
+	}
+
+	term := os.Getenv("TERM")
+	if term == "" {
+		term = "xterm-256color"
+	}
+	c := &Cast{
+		Header: Header{
+			Version:   2,
+			Width:     width,
+			Height:    height,
+			Timestamp: time.Now().Unix(),
+			Env: map[string]string{
+				"SHELL": os.Getenv("SHELL"),
+				"TERM":  term,
+			},
+			Title: r.Title,
+		},
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- r.read(master, start, c)
+	}()
+
+	err = run(tty)
+	// The reads end once the output is drained and no process has the
+	// terminal open anymore.
+	tty.Close()
+	if rerr := <-done; err == nil {
+		err = rerr
+	}
+	return c, err
+}
+
+// read records the output of the terminal as events until it is closed.
+func (r *Recorder) read(master io.Reader, start time.Time, c *Cast) error {
+	var (
+		buf     = make([]byte, 32*1024)
+		pending []byte
+	)
+	record := func(data []byte) {
+		c.Events = append(c.Events, Event{
+			Time: time.Since(start).Seconds(),
+			Data: string(data),
+		})
+		if r.Echo != nil {
+			r.Echo.Write(data)
+		}
+	}
+	for {
+		n, err := master.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			var out []byte
+			out, pending = split(pending)
+			if len(out) > 0 {
+				record(out)
+			}
+		}
+		if err == nil {
+			continue
+		}
+
+		// The output may end with an incomplete UTF-8 sequence.
+		if len(pending) > 0 {
+			record(pending)
+		}
+		// Reading a terminal which was closed fails with EIO on Linux.
+		if errors.Is(err, io.EOF) || errors.Is(err, syscall.EIO) || errors.Is(err, os.ErrClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+// split returns the output to record from the buffered output and the rest,
+// an incomplete UTF-8 sequence at the end, which is held back until more
+// output is read.
+func split(b []byte) (out, rest []byte) {
+	i := len(b) - incompleteRune(b)
+	return b[:i], append([]byte(nil), b[i:]...)
+}
+
+// incompleteRune returns the length of the incomplete UTF-8 sequence at the
+// end of b, if any.
+func incompleteRune(b []byte) int {
+	for n := 1; n <= utf8.UTFMax && n <= len(b); n++ {
+		if c := b[len(b)-n]; utf8.RuneStart(c) {
+			if c >= utf8.RuneSelf && !utf8.FullRune(b[len(b)-n:]) {
+				return n
+			}
+			return 0
+		}
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+// Package fsutil provides file helpers shared by the nbe packages.
+package fsutil
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CopyFile copies the file at src to dst, creating the parent directories of
+// dst as needed.
+func CopyFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(dst)
+	if dir != "" {
+		os.MkdirAll(dir, 0755)
+	}
+
+	c, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(c, f)
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
+
+// CreateFile writes b to the file n, creating the parent directories as
+// needed.
+func CreateFile(n string, b []byte) error {
+	dir := filepath.Dir(n)
+	if dir != "" {
+		os.MkdirAll(dir, 0755)
+	}
+
+	c, err := os.Create(n)
+	if err != nil {
+		return err
+	}
+	_, err = c.Write(b)
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
+
+// CopyDirContents copies the files and directories within src to dst.
+func CopyDirContents(src, dst string) error {
+	return fs.WalkDir(os.DirFS(src), ".", func(path string, info fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		dstpath := filepath.Join(dst, path)
+		// Ensure any directories are created..
+		if info.IsDir() {
+			return os.MkdirAll(dstpath, 0755)
+		}
+		sf, err := os.Open(filepath.Join(src, path))
+		if err != nil {
+			return err
+		}
+		defer sf.Close()
+		df, err := os.Create(dstpath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(df, sf)
+		return err
+	})
+}
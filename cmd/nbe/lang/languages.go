@@ -0,0 +1,270 @@
+// Package lang provides the registry of client languages and tools the
+// examples are written in.
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	_ "embed"
+)
+
+//go:embed languages.yaml
+var defaultLanguagesFile []byte
+
+// Names of the built-in languages, see languages.yaml.
+const (
+	Shell     = "shell"
+	CLI       = "cli"
+	Go        = "go"
+	Rust      = "rust"
+	Java      = "java"
+	DotNet    = "dotnet"
+	CSharp    = "csharp"
+	Deno      = "deno"
+	Node      = "node"
+	WebSocket = "websocket"
+	C         = "c"
+	Python    = "python"
+	Ruby      = "ruby"
+	Elixir    = "elixir"
+	Crystal   = "crystal"
+)
+
+// Default is the registry used when none is set explicitly. It is initialized
+// with the built-in languages and may be extended with Load.
+var Default = New()
+
+// Language describes a client language or tool that examples can be written
+// in. All subsystems, parsing, rendering, running and versioning, derive their
+// language-specific behavior from this.
+type Language struct {
+	// Name of the client directory, e.g. go.
+	Name string `yaml:"name"`
+	// Display label for the language tabs.
+	Label string `yaml:"label"`
+	// Main file the comment blocks are extracted from. If not set, clients
+	// are rendered as shell scripts.
+	Main string `yaml:"main"`
+	// Chroma lexer used for syntax highlighting, defaults to the name.
+	Lexer string `yaml:"lexer"`
+	// Directory under docker/ containing the default image files, defaults
+	// to the name.
+	Docker string `yaml:"docker"`
+	// If true, the language does not get a language tab.
+	Hidden bool `yaml:"hidden"`
+	// Name of another language whose settings are used for unset fields.
+	Extends string `yaml:"extends"`
+	// Comment and string literal syntax for the block parser.
+	Syntax *Syntax `yaml:"syntax"`
+	// Client library dependencies whose versions can be set.
+	Dependencies []*Dependency `yaml:"dependencies"`
+}
+
+// Dependency describes where a client library version is declared.
+type Dependency struct {
+	// Manifest file, relative to the docker or client directory.
+	File string `yaml:"file"`
+	// Regex matching the declaration of the dependency.
+	Pattern string `yaml:"pattern"`
+	// Replacement for the match, with %s substituted by the version.
+	Replace string `yaml:"replace"`
+	// If true, the server version is used rather than the client version.
+	Server bool `yaml:"server"`
+	// If true, the file is located in each example client directory rather
+	// than in the docker directory.
+	Examples bool `yaml:"examples"`
+	// Command to run in the directory after the file has been updated.
+	Command []string `yaml:"command"`
+
+	re *regexp.Regexp
+}
+
+// Regexp returns the compiled pattern.
+func (d *Dependency) Regexp() *regexp.Regexp {
+	return d.re
+}
+
+// LexerName returns the chroma lexer name for the language.
+func (l *Language) LexerName() string {
+	if l.Lexer != "" {
+		return l.Lexer
+	}
+	return l.Name
+}
+
+// DockerDir returns the name of the directory under docker/ for the language.
+func (l *Language) DockerDir() string {
+	if l.Docker != "" {
+		return l.Docker
+	}
+	return l.Name
+}
+
+// merge sets the unset fields of l from b.
+func (l *Language) merge(b *Language) {
+	if l.Label == "" {
+		l.Label = b.Label
+	}
+	if l.Main == "" {
+		l.Main = b.Main
+	}
+	if l.Lexer == "" {
+		l.Lexer = b.Lexer
+	}
+	if l.Docker == "" {
+		l.Docker = b.Docker
+	}
+	if l.Syntax == nil {
+		l.Syntax = b.Syntax
+	}
+	if l.Dependencies == nil {
+		l.Dependencies = b.Dependencies
+	}
+}
+
+// Registry is an ordered set of languages.
+type Registry struct {
+	list   []*Language
+	byName map[string]*Language
+}
+
+type languagesFile struct {
+	Languages []*Language `yaml:"languages"`
+}
+
+// Get returns the language by name.
+func (r *Registry) Get(name string) (*Language, bool) {
+	l, ok := r.byName[name]
+	return l, ok
+}
+
+// Label returns the display label of the language, or the name if unknown.
+func (r *Registry) Label(name string) string {
+	if l, ok := r.byName[name]; ok {
+		return l.Label
+	}
+	return name
+}
+
+// All returns all languages in order.
+func (r *Registry) All() []*Language {
+	return r.list
+}
+
+// Tabs returns the languages that are shown as tabs, in order.
+func (r *Registry) Tabs() []*Language {
+	var ls []*Language
+	for _, l := range r.list {
+		if !l.Hidden {
+			ls = append(ls, l)
+		}
+	}
+	return ls
+}
+
+// WithOrder returns a copy of the registry with the named languages first,
+// in the given order, followed by the others in their current order.
+func (r *Registry) WithOrder(names []string) (*Registry, error) {
+	x := &Registry{
+		byName: r.byName,
+	}
+	first := make(map[string]bool)
+	for _, n := range names {
+		l, ok := r.byName[n]
+		if !ok {
+			return nil, fmt.Errorf("unknown language %q", n)
+		}
+		if first[n] {
+			continue
+		}
+		first[n] = true
+		x.list = append(x.list, l)
+	}
+	for _, l := range r.list {
+		if !first[l.Name] {
+			x.list = append(x.list, l)
+		}
+	}
+	return x, nil
+}
+
+// Add adds or overrides languages from the languages file contents. An entry
+// whose name matches an existing language overrides the fields it sets.
+func (r *Registry) Add(b []byte) error {
+	var f languagesFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return err
+	}
+
+	for i, l := range f.Languages {
+		if l.Name == "" {
+			return fmt.Errorf("language %d: name is required", i)
+		}
+
+		if l.Extends != "" {
+			b, ok := r.byName[l.Extends]
+			if !ok {
+				return fmt.Errorf("language %q: extends unknown language %q", l.Name, l.Extends)
+			}
+			l.merge(b)
+		}
+
+		for _, d := range l.Dependencies {
+			re, err := regexp.Compile(d.Pattern)
+			if err != nil {
+				return fmt.Errorf("language %q: dependency %s: %w", l.Name, d.File, err)
+			}
+			d.re = re
+		}
+
+		if x, ok := r.byName[l.Name]; ok {
+			l.merge(x)
+			*x = *l
+			continue
+		}
+
+		if l.Label == "" {
+			l.Label = l.Name
+		}
+		if l.Main != "" && l.Syntax == nil {
+			return fmt.Errorf("language %q: syntax is required", l.Name)
+		}
+
+		r.list = append(r.list, l)
+		r.byName[l.Name] = l
+	}
+
+	return nil
+}
+
+// New returns a registry with the built-in languages.
+func New() *Registry {
+	r := &Registry{
+		byName: make(map[string]*Language),
+	}
+	if err := r.Add(defaultLanguagesFile); err != nil {
+		panic(fmt.Sprintf("default languages: %s", err))
+	}
+	return r
+}
+
+// Load extends the registry with the languages declared in the file at path.
+// A file that does not exist is ignored.
+func (r *Registry) Load(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := r.Add(b); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
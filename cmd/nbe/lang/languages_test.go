@@ -0,0 +1,56 @@
+package lang
+
+import "testing"
+
+func checkEqual[T comparable](t *testing.T, a, b T) {
+	t.Helper()
+	if a != b {
+		t.Error("not equal")
+	}
+}
+
+func TestLanguageRegistry(t *testing.T) {
+	r := New()
+
+	err := r.Add([]byte(`
+languages:
+  - name: kotlin
+    label: Kotlin
+    main: main.kt
+    extends: java
+  - name: go
+    label: Golang
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, ok := r.Get("kotlin")
+	if !ok {
+		t.Fatal("expected kotlin")
+	}
+	checkEqual(t, l.Syntax.LineComments[0], "//")
+	checkEqual(t, l.DockerDir(), "kotlin")
+
+	l, _ = r.Get(Go)
+	checkEqual(t, l.Label, "Golang")
+	checkEqual(t, l.Main, "main.go")
+
+	tabs := r.Tabs()
+	checkEqual(t, tabs[0].Name, CLI)
+	checkEqual(t, tabs[len(tabs)-1].Name, "kotlin")
+
+	o, err := r.WithOrder([]string{"kotlin", Python})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tabs = o.Tabs()
+	checkEqual(t, tabs[0].Name, "kotlin")
+	checkEqual(t, tabs[1].Name, Python)
+	checkEqual(t, tabs[2].Name, CLI)
+	checkEqual(t, len(o.All()), len(r.All()))
+	checkEqual(t, r.Tabs()[0].Name, CLI)
+
+	_, err = r.WithOrder([]string{"cobol"})
+	checkEqual(t, err != nil, true)
+}
@@ -0,0 +1,92 @@
+package lang
+
+// Syntax describes the lexical elements of a language the block parser needs
+// to be aware of, specifically comments and string literals. Everything else
+// is considered code.
+type Syntax struct {
+	// Line comment delimiters, e.g. "//" or "#".
+	LineComments []string `yaml:"line_comments"`
+
+	// Block comment delimiters, e.g. {"/*", "*/"}.
+	BlockComments [][2]string `yaml:"block_comments"`
+
+	// If true, block comments can be nested, e.g. Rust.
+	NestedComments bool `yaml:"nested_comments"`
+
+	// Documentation comments which are only recognized as the first token of
+	// a statement, e.g. Python docstrings, Elixir @doc attributes or Rust ///
+	// comments. Elsewhere these are treated as code, or as a line comment if
+	// they start with one.
+	DocComments []DocComment `yaml:"doc_comments"`
+
+	// If true, a line comment must start at a word boundary. This is the case
+	// for shell where `#` is valid within a word, e.g. `$#` or `${#arr[@]}`.
+	WordComments bool `yaml:"word_comments"`
+
+	// If true, lines beginning with `#!` are treated as code.
+	Shebang bool `yaml:"shebang"`
+
+	// If true, shell-style heredocs are recognized and their bodies are
+	// treated as code.
+	Heredocs bool `yaml:"heredocs"`
+
+	// String literals, in order of precedence. Longer delimiters sharing a
+	// prefix with a shorter one must come first, e.g. `"""` before `"`.
+	Strings []StringSyntax `yaml:"strings"`
+}
+
+// DocComment describes the delimiters of a documentation comment.
+type DocComment struct {
+	Open string `yaml:"open"`
+	// If empty, the comment ends with the line, e.g. /// in Rust.
+	Close string `yaml:"close"`
+
+	// If true, the delimiters are only recognized at the start of a line,
+	// e.g. =begin and =end in Ruby.
+	LineStart bool `yaml:"line_start"`
+}
+
+// CommentDelims returns the open and close delimiters of the block and doc
+// comments, excluding the doc comments ending with the line.
+func (s *Syntax) CommentDelims() [][2]string {
+	var ds [][2]string
+	for _, d := range s.DocComments {
+		if d.Close != "" {
+			ds = append(ds, [2]string{d.Open, d.Close})
+		}
+	}
+	return append(ds, s.BlockComments...)
+}
+
+// LineCommentDelims returns the delimiters of the line comments, including
+// the doc comments ending with the line.
+func (s *Syntax) LineCommentDelims() []string {
+	ds := append([]string(nil), s.LineComments...)
+	for _, d := range s.DocComments {
+		if d.Close == "" {
+			ds = append(ds, d.Open)
+		}
+	}
+	return ds
+}
+
+// StringSyntax describes the delimiters of a string literal.
+type StringSyntax struct {
+	Open  string `yaml:"open"`
+	Close string `yaml:"close"`
+
+	// Escape character within the literal, empty if not supported.
+	Escape string `yaml:"escape"`
+
+	// If true, the literal can span multiple lines.
+	Multiline bool `yaml:"multiline"`
+
+	// If true, the literal holds a single, possibly escaped, character. This
+	// is used to disambiguate from other uses of the quote, such as lifetimes
+	// in Rust.
+	Char bool `yaml:"char"`
+
+	// If true, the open delimiter may be followed by any number of `#` which
+	// must be repeated after the close delimiter, e.g. r#"..."# in Rust.
+	Hashes bool `yaml:"hashes"`
+}
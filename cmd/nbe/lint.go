@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+)
+
+func writeLintIssues(w io.Writer, issues []*examples.LintIssue, format string) error {
+	switch format {
+	case "json":
+		if issues == nil {
+			issues = []*examples.LintIssue{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(issues)
+
+	case "text", "":
+		for _, i := range issues {
+			fmt.Fprintln(w, i)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown format %q", format)
+}
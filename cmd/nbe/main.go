@@ -3,14 +3,17 @@ package main
 import (
 	"errors"
 	"fmt"
-	"io/fs"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 
 	"github.com/urfave/cli/v2"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/runner"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/site"
 )
 
 func main() {
@@ -25,6 +28,16 @@ var (
 	app = cli.App{
 		Name:  "nbe",
 		Usage: "CLI for using the NATS by Example repo.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "languages",
+				Usage: "Path to a languages file extending the built-in language registry.",
+				Value: "languages.yaml",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			return lang.Default.Load(c.String("languages"))
+		},
 		Commands: []*cli.Command{
 			&runCmd,
 			&buildCmd,
@@ -33,6 +46,9 @@ var (
 			&generateCmd,
 			&ejectCmd,
 			&setVersionsCmd,
+			&lintCmd,
+			&checkLinksCmd,
+			&exportCmd,
 		},
 	}
 
@@ -47,7 +63,7 @@ var (
 				return err
 			}
 
-			b := ImageBuilder{
+			b := runner.ImageBuilder{
 				Repo:    repo,
 				Example: example,
 				Verbose: true,
@@ -83,7 +99,7 @@ var (
 				return err
 			}
 
-			b := Ejecter{
+			b := runner.Ejecter{
 				Repo:    repo,
 				Example: example,
 				Dir:     dir,
@@ -175,7 +191,7 @@ the runtime.`,
 
 			var examples []string
 			// If a client is specified, run all examples for that client.
-			if _, ok := availableLanguages[target]; ok {
+			if _, ok := lang.Default.Get(target); ok {
 				examples, _ = filepath.Glob(fmt.Sprintf("examples/*/*/%s", target))
 			} else if target == "all" {
 				examples, _ = filepath.Glob("examples/*/*/*")
@@ -193,7 +209,7 @@ the runtime.`,
 
 			for _, example := range examples {
 				if image == "" {
-					b := ImageBuilder{
+					b := runner.ImageBuilder{
 						Name:    name,
 						Repo:    repo,
 						Example: example,
@@ -207,11 +223,11 @@ the runtime.`,
 
 					if !keep {
 						// Best effort.
-						defer removeImage(image)
+						defer runner.RemoveImage(image)
 					}
 				}
 
-				r := ComposeRunner{
+				r := runner.ComposeRunner{
 					Name:    name,
 					Repo:    repo,
 					Example: example,
@@ -245,11 +261,61 @@ the runtime.`,
 				Usage: "HTTP bind address.",
 				Value: "localhost:8000",
 			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Rebuild the site when the examples, static files or theme change, reloading the open pages. Without --source, the site is built from the examples directory to --dir.",
+			},
+			&cli.StringFlag{
+				Name:  "source",
+				Usage: "Source directory containing the examples. If set, the pages are rendered on demand and kept in memory rather than served from --dir.",
+			},
+			&cli.StringFlag{
+				Name:  "static",
+				Usage: "Directory containing the static files, with --source or --watch.",
+				Value: "static",
+			},
+			&cli.StringFlag{
+				Name:  "theme",
+				Usage: "Directory of templates overriding the embedded ones, with --source or --watch.",
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to the site configuration file, with --source or --watch.",
+				Value: "site.yaml",
+			},
+			&cli.StringFlag{
+				Name:  "base-url",
+				Usage: "URL the site is served from. Overrides the configuration file.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			addr := c.String("addr")
 			dir := c.String("dir")
-			return http.ListenAndServe(addr, http.FileServer(http.Dir(dir)))
+			source := c.String("source")
+			if !c.Bool("watch") && source == "" {
+				return http.ListenAndServe(addr, http.FileServer(http.Dir(dir)))
+			}
+
+			config, err := loadSiteConfig(c)
+			if err != nil {
+				return err
+			}
+			s := site.Server{
+				Builder: &site.Builder{
+					Source: source,
+					Static: c.String("static"),
+					Output: dir,
+					Config: config,
+					Theme:  c.String("theme"),
+				},
+				InMemory: source != "",
+				Watch:    c.Bool("watch"),
+			}
+			if source == "" {
+				s.Builder.Source = "examples"
+			}
+			log.Printf("serving on http://%s", addr)
+			return s.ListenAndServe(addr)
 		},
 	}
 
@@ -308,7 +374,8 @@ the runtime.`,
 				useMatch = true
 			}
 
-			root, err := parseExamples(source)
+			var p examples.Parser
+			root, err := p.Parse(source)
 			if err != nil {
 				return err
 			}
@@ -316,7 +383,7 @@ the runtime.`,
 			// Enumerate all the client implementations.
 			for _, c := range root.Categories {
 				for _, e := range c.Examples {
-					for _, i := range e.Clients {
+					for _, i := range e.SortedClients(lang.Default) {
 						if _, ok := matches[i.Path]; ok || !useMatch {
 							log.Printf("%s: recording", i.Path)
 							if err := generateRecording(repo, i.Path, recreate); err != nil {
@@ -351,53 +418,51 @@ the runtime.`,
 			},
 			&cli.StringFlag{
 				Name:  "output",
-				Usage: "Directory the HTML files will be written to. Note, this will delete the existing directory if present.",
+				Usage: "Directory the HTML files will be written to. Only pages whose inputs have changed since the last build are rendered.",
 				Value: "html",
 			},
+			&cli.BoolFlag{
+				Name:  "clean",
+				Usage: "Delete the existing contents of the output directory and render all pages.",
+			},
+			&cli.BoolFlag{
+				Name:  "check",
+				Usage: "Build the site twice to temporary directories and fail if the output differs. The output directory is not written.",
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to the site configuration file, e.g. for the base URL, title and source repository.",
+				Value: "site.yaml",
+			},
+			&cli.StringFlag{
+				Name:  "theme",
+				Usage: "Directory of templates overriding the embedded ones by name, e.g. client.html, or adding partials.",
+			},
+			&cli.StringFlag{
+				Name:  "base-url",
+				Usage: "URL the site is served from, used for the canonical links, sitemap and feed. Overrides the configuration file.",
+			},
 		},
 		Action: func(c *cli.Context) error {
-			source := c.String("source")
-			output := c.String("output")
-			static := c.String("static")
-
-			root, err := parseExamples(source)
+			config, err := loadSiteConfig(c)
 			if err != nil {
 				return err
 			}
 
-			if _, err := os.Stat(output); os.IsNotExist(err) {
-				if err := os.MkdirAll(output, 0755); err != nil {
-					return err
-				}
-			} else {
-				entries, err := os.ReadDir(output)
-				if err != nil {
-					return err
-				}
-				for _, e := range entries {
-					if err := os.RemoveAll(filepath.Join(output, e.Name())); err != nil {
-						return err
-					}
-				}
+			b := site.Builder{
+				Source: c.String("source"),
+				Static: c.String("static"),
+				Output: c.String("output"),
+				Clean:  c.Bool("clean"),
+				Config: config,
+				Theme:  c.String("theme"),
 			}
 
-			entries, err := fs.ReadDir(os.DirFS(static), ".")
-			if err != nil {
-				return err
+			if c.Bool("check") {
+				return b.Check()
 			}
 
-			for _, e := range entries {
-				b, err := ioutil.ReadFile(filepath.Join(static, e.Name()))
-				if err != nil {
-					return err
-				}
-				err = createFile(filepath.Join(output, e.Name()), b)
-				if err != nil {
-					return err
-				}
-			}
-
-			return generateDocs(root, output)
+			return b.Run()
 		},
 	}
 
@@ -413,7 +478,148 @@ the runtime.`,
 		},
 		Action: func(c *cli.Context) error {
 			file := c.String("versions")
-			return setVersions(file)
+			return runner.SetVersions(file, lang.Default)
+		},
+	}
+
+	lintCmd = cli.Command{
+		Name:  "lint",
+		Usage: "Validate the examples tree, exiting non-zero if there are issues.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "source",
+				Usage: "Source directory containing the examples.",
+				Value: "examples",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format, text or json.",
+				Value: "text",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			source := c.String("source")
+			format := c.String("format")
+
+			var p examples.Parser
+			issues, err := p.Lint(source)
+			if err != nil {
+				return err
+			}
+
+			if err := writeLintIssues(os.Stdout, issues, format); err != nil {
+				return err
+			}
+
+			if len(issues) > 0 {
+				return fmt.Errorf("%d issue(s) found", len(issues))
+			}
+			return nil
+		},
+	}
+
+	checkLinksCmd = cli.Command{
+		Name:  "check-links",
+		Usage: "Check the links of the rendered site, exiting non-zero if there are broken links.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "dir",
+				Usage: "Directory containing the rendered HTML.",
+				Value: "html",
+			},
+			&cli.StringFlag{
+				Name:  "external",
+				Usage: "How external links are checked: skip, offline against the allowlist and snapshot, or online.",
+				Value: site.ExternalSkip,
+			},
+			&cli.StringSliceFlag{
+				Name:  "allow",
+				Usage: "Prefix of external URLs assumed to be valid, may be repeated.",
+			},
+			&cli.StringFlag{
+				Name:  "snapshot",
+				Usage: "JSON file of the external link statuses, read in offline mode and written in online mode.",
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to the site configuration file, links to the base URL are checked as internal links.",
+				Value: "site.yaml",
+			},
+			&cli.StringFlag{
+				Name:  "base-url",
+				Usage: "URL the site is served from. Overrides the configuration file.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			config, err := loadSiteConfig(c)
+			if err != nil {
+				return err
+			}
+
+			lc := site.LinkChecker{
+				Dir:      c.String("dir"),
+				BaseURL:  config.BaseURL,
+				External: c.String("external"),
+				Allow:    c.StringSlice("allow"),
+				Snapshot: c.String("snapshot"),
+			}
+			broken, err := lc.Run()
+			if err != nil {
+				return err
+			}
+
+			for _, l := range broken {
+				fmt.Println(l)
+			}
+			if len(broken) > 0 {
+				return fmt.Errorf("%d broken link(s) found", len(broken))
+			}
+			return nil
+		},
+	}
+
+	exportCmd = cli.Command{
+		Name:  "export",
+		Usage: "Export the examples as Markdown or MDX files for other documentation systems.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "source",
+				Usage: "Source directory containing the examples.",
+				Value: "examples",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Format of the files, md or mdx with a tab per client for Docusaurus.",
+				Value: site.FormatMarkdown,
+			},
+			&cli.StringFlag{
+				Name:     "out",
+				Usage:    "Directory the files will be written to, with a directory per category.",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to the site configuration file, e.g. for the source repository and base URL of links to the site.",
+				Value: "site.yaml",
+			},
+			&cli.StringFlag{
+				Name:  "base-url",
+				Usage: "URL the site is served from. Overrides the configuration file.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			config, err := loadSiteConfig(c)
+			if err != nil {
+				return err
+			}
+
+			x := site.Exporter{
+				Source: c.String("source"),
+				Output: c.String("out"),
+				Format: c.String("format"),
+				Config: config,
+			}
+			return x.Run()
 		},
 	}
 )
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/runner"
+)
+
+type Result struct {
+	Example       string
+	ServerVersion string
+	Client        string
+	ClientVersion string
+	Error         error
+}
+
+func runMatrix(workers int, path string, repo string, paths []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, err := runner.OpenMatrixFile(path)
+	if err != nil {
+		return err
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(workers)
+	workch := make(chan *runner.Job, workers)
+	resch := make(chan *Result, 1000)
+
+	// Start the workers.
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j, ok := <-workch:
+					if !ok {
+						return
+					}
+
+					err := j.Run()
+
+					fmt.Fprintf(os.Stderr, "* %q: server (%s) x %s (%s)\n", j.Example, j.ServerVersion, j.Client, j.ClientVersion)
+					r := &Result{
+						Example:       j.Example,
+						ServerVersion: j.ServerVersion,
+						Client:        j.Client,
+						ClientVersion: j.ClientVersion,
+						Error:         err,
+					}
+
+					select {
+					case resch <- r:
+					case <-time.After(time.Second):
+						log.Printf("timeout sending on result channel")
+						return
+					}
+				}
+			}
+		}(i)
+	}
+
+	// Prepare the jobs to queue up.
+	cm := make(map[string][]string)
+	checked := make(map[string]bool)
+
+	t0 := time.Now()
+
+	for _, e := range paths {
+		client := filepath.Base(e)
+
+		if _, ok := lang.Default.Get(client); !ok {
+			return fmt.Errorf("unknown client: %s", client)
+		}
+		versions := m.Clients[client]
+
+		cm[client] = versions
+
+		// Warn once per example about server versions it does not support,
+		// based on its min_server_version.
+		dir := filepath.Dir(e)
+		if !strings.HasPrefix(dir, "examples/") {
+			dir = filepath.Join("examples", dir)
+		}
+		if !checked[dir] {
+			checked[dir] = true
+			ex, err := examples.ReadExampleMeta(filepath.Join(repo, dir))
+			if err != nil {
+				log.Printf("%s: %s", dir, err)
+			} else {
+				for _, s := range m.Server {
+					if !ex.SupportsServer(s) {
+						log.Printf("%s: server %s is below the minimum version %s", dir, s, ex.MinServerVersion)
+					}
+				}
+			}
+		}
+
+		for _, s := range m.Server {
+			for _, c := range versions {
+				workch <- &runner.Job{
+					Repo:          repo,
+					Example:       e,
+					Client:        client,
+					ServerVersion: s,
+					ClientVersion: c,
+				}
+			}
+		}
+	}
+
+	close(workch)
+	go func() {
+		wg.Wait()
+		fmt.Fprintf(os.Stderr, "Total time... %s\n", time.Since(t0))
+		close(resch)
+	}()
+
+	results := make(map[string]map[string]map[[2]string]*Result)
+
+	for r := range resch {
+		e, ok := results[r.Client]
+		if !ok {
+			e = make(map[string]map[[2]string]*Result)
+			results[r.Client] = e
+		}
+		m, ok := e[r.Example]
+		if !ok {
+			m = make(map[[2]string]*Result)
+			e[r.Example] = m
+		}
+		k := [2]string{r.ServerVersion, r.ClientVersion}
+		m[k] = r
+	}
+
+	for _, l := range lang.Default.All() {
+		rs, ok := results[l.Name]
+		if !ok {
+			continue
+		}
+
+		clientVersions := cm[l.Name]
+
+		fmt.Printf("# %s\n", l.Label)
+
+		for e, cr := range rs {
+			fmt.Printf("## %s\n", e)
+
+			tw := tablewriter.NewWriter(os.Stdout)
+			head := append([]string{""}, clientVersions...)
+			tw.SetHeader(head)
+
+			// Each version is a row.
+			for _, s := range m.Server {
+				row := []string{s}
+
+				for _, c := range clientVersions {
+					k := [2]string{s, c}
+					r := cr[k]
+					if r.Error == nil {
+						row = append(row, "OK")
+					} else {
+						log.Println(r.Error)
+						row = append(row, "ERR")
+					}
+				}
+
+				tw.Append(row)
+			}
+
+			tw.Render()
+		}
+	}
+
+	return nil
+}
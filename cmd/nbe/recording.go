@@ -1,14 +1,21 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strings"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/internal/asciicast"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/runner"
+)
+
+// Size of the terminal of the recordings, as shown by the player on the
+// client pages.
+const (
+	recordingWidth  = 120
+	recordingHeight = 24
 )
 
 func generateRecording(repo, example string, recreate bool) error {
@@ -22,9 +29,11 @@ func generateRecording(repo, example string, recreate bool) error {
 		}
 	}
 
+	var cast *asciicast.Cast
+
 	// Does not exist, or force recreate.
 	if err != nil || recreate {
-		b := ImageBuilder{
+		b := runner.ImageBuilder{
 			Repo:    repo,
 			Example: example,
 			Verbose: true,
@@ -37,64 +46,53 @@ func generateRecording(repo, example string, recreate bool) error {
 
 		name := strings.TrimPrefix(example, "examples/")
 
-		tempFile, _ := os.CreateTemp("", "")
-		tempName := tempFile.Name()
-		tempFile.Close()
-		defer os.Remove(tempName)
-
-		// Generate the recording using the pre-built image.
-		c := exec.Command(
-			"asciinema", "rec",
-			"--overwrite",
-			"--command", fmt.Sprintf("nbe run --no-ansi=true --quiet --image=%s %s", image, name),
-			"--title", fmt.Sprintf("NATS by Example: %s", name),
-			tempName,
-		)
-
-		c.Stdout = os.Stdout
-		c.Stderr = os.Stderr
-
-		err = c.Run()
+		// Run the example with the pre-built image under a terminal, so its
+		// output is as in an interactive shell.
+		rec := asciicast.Recorder{
+			Width:  recordingWidth,
+			Height: recordingHeight,
+			Title:  fmt.Sprintf("NATS by Example: %s", name),
+			// Show the output while it is recorded, e.g. in the CI logs.
+			Echo: os.Stdout,
+		}
+		cast, err = rec.Record(func(tty *os.File) error {
+			r := runner.ComposeRunner{
+				Repo:    repo,
+				Example: example,
+				NoAnsi:  true,
+				// Keep the progress of Docker Compose starting the
+				// dependencies out of the recording.
+				DetachDeps: true,
+				Stdout:     tty,
+				Stderr:     tty,
+			}
+			return r.Run(image)
+		})
 		if err != nil {
-			return fmt.Errorf("asciinema rec: %w", err)
+			if cast != nil {
+				return fmt.Errorf("record: %w\n%s", err, cast.Text())
+			}
+			return fmt.Errorf("record: %w", err)
 		}
 
-		contents, err := os.ReadFile(tempName)
+		var buf bytes.Buffer
+		if err := cast.Encode(&buf); err != nil {
+			return err
+		}
+		if err := os.WriteFile(castFile, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Open(castFile)
 		if err != nil {
 			return err
 		}
-
-		contents = removeComposeLines(contents)
-
-		os.WriteFile(castFile, contents, 0644)
-	}
-
-	c := exec.Command(
-		"asciinema", "cat",
-		castFile,
-	)
-	output, err := c.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("asciinema cat: %w\n%s", err, string(output))
-	}
-
-	return os.WriteFile(outputFile, output, 0644)
-}
-
-func removeComposeLines(output []byte) []byte {
-	re := regexp.MustCompile(`(Network|Container)\s+[^\s]+\s+(Creating|Created|Starting|Started)`)
-
-	buf := bytes.NewBuffer(nil)
-	sc := bufio.NewScanner(bytes.NewReader(output))
-
-	for sc.Scan() {
-		line := sc.Bytes()
-
-		if re.Find(line) == nil {
-			buf.Write(line)
-			buf.WriteByte('\n')
+		cast, err = asciicast.Decode(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", castFile, err)
 		}
 	}
 
-	return buf.Bytes()
+	return os.WriteFile(outputFile, []byte(cast.Text()), 0644)
 }
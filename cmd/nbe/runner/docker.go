@@ -1,10 +1,11 @@
-package main
+// Package runner builds the container images of the examples and runs them
+// with Docker Compose.
+package runner
 
 import (
 	"bytes"
 	"fmt"
 	"io"
-	"io/fs"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -13,72 +14,21 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-)
-
-func copyFile(src, dst string) error {
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	dir := filepath.Dir(dst)
-	if dir != "" {
-		os.MkdirAll(dir, 0755)
-	}
-
-	c, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(c, f)
-	if err != nil {
-		return err
-	}
-	return c.Close()
-}
 
-func createFile(n string, b []byte) error {
-	dir := filepath.Dir(n)
-	if dir != "" {
-		os.MkdirAll(dir, 0755)
-	}
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/internal/fsutil"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
 
-	c, err := os.Create(n)
-	if err != nil {
-		return err
+// languageDockerDir returns the directory containing the default image files
+// for the language.
+func languageDockerDir(langs *lang.Registry, repo, name string) string {
+	if langs == nil {
+		langs = lang.Default
 	}
-	_, err = c.Write(b)
-	if err != nil {
-		return err
+	if l, ok := langs.Get(name); ok {
+		name = l.DockerDir()
 	}
-	return c.Close()
-}
-
-func copyDirContents(src, dst string) error {
-	return fs.WalkDir(os.DirFS(src), ".", func(path string, info fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		dstpath := filepath.Join(dst, path)
-		// Ensure any directories are created..
-		if info.IsDir() {
-			return os.MkdirAll(dstpath, 0755)
-		}
-		sf, err := os.Open(filepath.Join(src, path))
-		if err != nil {
-			return err
-		}
-		defer sf.Close()
-		df, err := os.Create(dstpath)
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(df, sf)
-		return err
-	})
+	return filepath.Join(repo, dockerDir, name)
 }
 
 type ImageBuilder struct {
@@ -91,6 +41,9 @@ type ImageBuilder struct {
 	Verbose bool
 	// Version overrides.
 	Versions *Versions
+	// Languages used to resolve the default image files and dependencies.
+	// Defaults to lang.Default.
+	Languages *lang.Registry
 	// Defaults to os.Stdout and os.Stderr. Set if these streams need to be
 	// explicitly captured.
 	Stdout io.Writer
@@ -116,7 +69,7 @@ func (r *ImageBuilder) Run() (string, error) {
 	}
 
 	clientDir := filepath.Join(r.Repo, example)
-	lang := filepath.Base(example)
+	client := filepath.Base(example)
 
 	var uid string
 	if r.Name != "" {
@@ -128,7 +81,7 @@ func (r *ImageBuilder) Run() (string, error) {
 	imageTag := fmt.Sprintf("%s:%s", filepath.Join("nbe", r.Example), uid)
 	imageTag = strings.Replace(imageTag, "\\", "/", -1) // when running on windows filepath use backslash, but this is executing on docker in unix.
 
-	defaultDir := filepath.Join(r.Repo, "docker", lang)
+	defaultDir := languageDockerDir(r.Languages, r.Repo, client)
 
 	// Create a temporary directory for the build context of the image.
 	// This will combine all files in the runtime-specific docker/ directory
@@ -141,18 +94,18 @@ func (r *ImageBuilder) Run() (string, error) {
 	defer os.RemoveAll(buildDir)
 
 	// Copy default files first.
-	if err := copyDirContents(defaultDir, buildDir); err != nil {
+	if err := fsutil.CopyDirContents(defaultDir, buildDir); err != nil {
 		return "", fmt.Errorf("copy default files: %w", err)
 	}
 
 	// Copy example files next..
-	if err := copyDirContents(clientDir, buildDir); err != nil {
+	if err := fsutil.CopyDirContents(clientDir, buildDir); err != nil {
 		return "", fmt.Errorf("copy client files: %w", err)
 	}
 
 	// Replace versions
 	if r.Versions != nil {
-		if err := replaceVersions(buildDir, r.Versions); err != nil {
+		if err := ReplaceVersions(buildDir, r.Versions, r.Languages); err != nil {
 			return "", fmt.Errorf("replace versions: %w", err)
 		}
 	}
@@ -179,7 +132,8 @@ func (r *ImageBuilder) Run() (string, error) {
 	return imageTag, nil
 }
 
-func removeImage(image string) error {
+// RemoveImage removes the image built by ImageBuilder.
+func RemoveImage(image string) error {
 	c := exec.Command("docker", "rmi", image)
 	return c.Run()
 }
@@ -200,8 +154,15 @@ type ComposeRunner struct {
 	Verbose bool
 	// If true, do not use ansi control characters.
 	NoAnsi bool
+	// If true, start the dependencies of the app in the background before
+	// running it, so only the output of the app is written to Stdout and
+	// Stderr. Ignored if Up is set.
+	DetachDeps bool
 	// Version overrides.
 	Versions *Versions
+	// Languages used to resolve the default image files and dependencies.
+	// Defaults to lang.Default.
+	Languages *lang.Registry
 	// Defaults to os.Stdout and os.Stderr. Set if these streams need to be
 	// explicitly captured.
 	Stdout io.Writer
@@ -229,7 +190,7 @@ func (r *ComposeRunner) Run(imageTag string) error {
 
 	clientDir := filepath.Join(r.Repo, example)
 	exampleDir := filepath.Dir(clientDir)
-	lang := filepath.Base(example)
+	client := filepath.Base(example)
 
 	// Check client directory first, fallback to example directory, then
 	// the language directory, finally the defaults.
@@ -243,7 +204,7 @@ func (r *ComposeRunner) Run(imageTag string) error {
 			if !os.IsNotExist(err) {
 				return err
 			}
-			composeFile = filepath.Join(r.Repo, "docker", lang, "docker-compose.yaml")
+			composeFile = filepath.Join(languageDockerDir(r.Languages, r.Repo, client), "docker-compose.yaml")
 			if _, err := os.Stat(composeFile); err != nil {
 				if !os.IsNotExist(err) {
 					return err
@@ -264,7 +225,7 @@ func (r *ComposeRunner) Run(imageTag string) error {
 		uid = uuid.New().String()[:8]
 	}
 
-	defaultDir := filepath.Join(r.Repo, "docker", lang)
+	defaultDir := languageDockerDir(r.Languages, r.Repo, client)
 
 	// Create a temporary directory for the build context of the image.
 	// This will combine all files in the runtime-specific docker/ directory
@@ -277,27 +238,27 @@ func (r *ComposeRunner) Run(imageTag string) error {
 	defer os.RemoveAll(buildDir)
 
 	// Copy default files first.
-	if err := copyDirContents(defaultDir, buildDir); err != nil {
+	if err := fsutil.CopyDirContents(defaultDir, buildDir); err != nil {
 		return err
 	}
 
 	// Copy example files next..
-	if err := copyDirContents(clientDir, buildDir); err != nil {
+	if err := fsutil.CopyDirContents(clientDir, buildDir); err != nil {
 		return err
 	}
 
 	buildComposeFile := filepath.Join(buildDir, "docker-compose.yaml")
-	if err := copyFile(composeFile, buildComposeFile); err != nil {
+	if err := fsutil.CopyFile(composeFile, buildComposeFile); err != nil {
 		return err
 	}
 
-	err = createFile(filepath.Join(buildDir, ".env"), []byte(fmt.Sprintf("IMAGE_TAG=%s", imageTag)))
+	err = fsutil.CreateFile(filepath.Join(buildDir, ".env"), []byte(fmt.Sprintf("IMAGE_TAG=%s", imageTag)))
 	if err != nil {
 		return fmt.Errorf("create .env: %w", err)
 	}
 
 	if r.Versions != nil {
-		if err := replaceVersions(buildDir, r.Versions); err != nil {
+		if err := ReplaceVersions(buildDir, r.Versions, r.Languages); err != nil {
 			return fmt.Errorf("replace versions: %w", err)
 		}
 	}
@@ -332,6 +293,51 @@ func (r *ComposeRunner) Run(imageTag string) error {
 		return fmt.Errorf("pull images: %w\n%s", err, stderrb.String())
 	}
 
+	detachDeps := r.DetachDeps && !r.Up
+	if detachDeps {
+		// List the services, to start all but the app.
+		cmd = exec.Command(
+			"docker",
+			"compose",
+			"--project-name", uid,
+			"--project-directory", buildDir,
+			"--file", buildComposeFile,
+			"config",
+			"--services",
+		)
+		stderrb.Reset()
+		cmd.Stderr = stderrb
+		out, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("list services: %w\n%s", err, stderrb.String())
+		}
+
+		var deps []string
+		for _, s := range strings.Fields(string(out)) {
+			if s != "app" {
+				deps = append(deps, s)
+			}
+		}
+
+		if len(deps) > 0 {
+			cmd = exec.Command(
+				"docker",
+				append([]string{
+					"compose",
+					"--project-name", uid,
+					"--project-directory", buildDir,
+					"--file", buildComposeFile,
+					"up",
+					"--detach",
+					"--wait",
+				}, deps...)...,
+			)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				return fmt.Errorf("start dependencies: %w\n%s", err, out)
+			}
+		}
+	}
+
 	if r.Up {
 		// Run the app container.
 		cmd = exec.Command(
@@ -350,9 +356,7 @@ func (r *ComposeRunner) Run(imageTag string) error {
 			progress = "quiet"
 		}
 
-		// Run the app container.
-		cmd = exec.Command(
-			"docker",
+		args := []string{
 			"compose",
 			"--ansi", ansi,
 			"--progress", progress,
@@ -362,8 +366,13 @@ func (r *ComposeRunner) Run(imageTag string) error {
 			"run",
 			"--no-TTY",
 			"--rm",
-			"app",
-		)
+		}
+		if detachDeps {
+			args = append(args, "--no-deps")
+		}
+
+		// Run the app container.
+		cmd = exec.Command("docker", append(args, "app")...)
 	}
 
 	cmd.Stdout = stdout
@@ -1,4 +1,4 @@
-package main
+package runner
 
 import (
 	"errors"
@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/internal/fsutil"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
 )
 
 type Ejecter struct {
@@ -18,6 +21,9 @@ type Ejecter struct {
 	Dir string
 	// Print out docker build output.
 	Verbose bool
+	// Languages used to resolve the default image files. Defaults to
+	// lang.Default.
+	Languages *lang.Registry
 	// Defaults to os.Stdout and os.Stderr. Set if these streams need to be
 	// explicitly captured.
 	Stdout io.Writer
@@ -43,9 +49,9 @@ func (r *Ejecter) Run() error {
 	}
 
 	exampleDir := filepath.Join(r.Repo, example)
-	lang := filepath.Base(example)
+	client := filepath.Base(example)
 
-	defaultDir := filepath.Join(r.Repo, "docker", lang)
+	defaultDir := languageDockerDir(r.Languages, r.Repo, client)
 
 	buildDir := r.Dir
 
@@ -68,18 +74,18 @@ func (r *Ejecter) Run() error {
 	}
 
 	composeFile := filepath.Join(r.Repo, "docker", "docker-compose.yaml")
-	err := copyFile(composeFile, filepath.Join(buildDir, "docker-compose.yaml"))
+	err := fsutil.CopyFile(composeFile, filepath.Join(buildDir, "docker-compose.yaml"))
 	if err != nil {
 		return fmt.Errorf("copy compoose file: %w", err)
 	}
 
 	// Copy default files first.
-	if err := copyDirContents(defaultDir, buildDir); err != nil {
+	if err := fsutil.CopyDirContents(defaultDir, buildDir); err != nil {
 		return fmt.Errorf("copy default files: %w", err)
 	}
 
 	// Copy example files next..
-	if err := copyDirContents(exampleDir, buildDir); err != nil {
+	if err := fsutil.CopyDirContents(exampleDir, buildDir); err != nil {
 		return fmt.Errorf("copy client files: %w", err)
 	}
 
@@ -0,0 +1,273 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+const (
+	dockerDir   = "docker"
+	examplesDir = "examples"
+)
+
+// Versions declares the server version and the client library version per
+// language, keyed by the language name.
+type Versions struct {
+	Server  string            `yaml:"server"`
+	Clients map[string]string `yaml:",inline"`
+}
+
+// Matrix declares the set of server versions and client library versions
+// per language, keyed by the language name.
+type Matrix struct {
+	Server  []string            `yaml:"server"`
+	Clients map[string][]string `yaml:",inline"`
+}
+
+// OpenVersionsFile reads the versions file at path.
+func OpenVersionsFile(path string) (*Versions, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var v Versions
+	err = yaml.Unmarshal(b, &v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &v, nil
+}
+
+// OpenMatrixFile reads the matrix file at path.
+func OpenMatrixFile(path string) (*Matrix, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var m Matrix
+	err = yaml.Unmarshal(b, &m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &m, nil
+}
+
+func findAndReplace(re *regexp.Regexp, rep string, path string) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		// Ignore if the file does not exist.
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	perm := st.Mode().Perm()
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if matches := re.FindAll(src, -1); len(matches) == 0 {
+		return nil
+		//return fmt.Errorf("%s: no matches found", path)
+	}
+
+	out := re.ReplaceAll(src, []byte(rep))
+	return ioutil.WriteFile(path, out, perm)
+}
+
+func findAndReplaceMulti(re *regexp.Regexp, rep string, paths ...string) error {
+	for _, p := range paths {
+		if err := findAndReplace(re, rep, p); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+var (
+	composeNatsImageRe = regexp.MustCompile(`image: (docker.io/)?nats:\d+\.\d+\.\d+`)
+	composeNatsImageT  = `image: docker.io/nats:%s`
+)
+
+func setComposeServerVersion(version string, base string) error {
+	err := examples.MultiErr{
+		findAndReplaceMulti(
+			composeNatsImageRe,
+			fmt.Sprintf(composeNatsImageT, version),
+			filepath.Join(base, "docker-compose.yaml"),
+			filepath.Join(base, "docker-compose.cluster.yaml"),
+		),
+	}
+	if err.Empty() {
+		return nil
+	}
+	return &err
+}
+
+// dependencyVersion returns the version the dependency should be set to.
+func dependencyVersion(l *lang.Language, d *lang.Dependency, vs *Versions) string {
+	if d.Server {
+		return vs.Server
+	}
+	return vs.Clients[l.Name]
+}
+
+// setDependency updates the version declared in the dependency's file
+// relative to dir. If a command is declared, it is run in dir afterwards.
+func setDependency(d *lang.Dependency, version string, dir string) error {
+	path := filepath.Join(dir, d.File)
+	if err := findAndReplace(d.Regexp(), fmt.Sprintf(d.Replace, version), path); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(d.Command) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	cmd := exec.Command(d.Command[0], d.Command[1:]...)
+	cmd.Dir = dir
+	return cmd.Run()
+}
+
+// SetVersions updates the docker directory and examples of the repo in the
+// working directory with the versions declared in the file at path. If langs
+// is nil, lang.Default is used.
+func SetVersions(path string, langs *lang.Registry) error {
+	if langs == nil {
+		langs = lang.Default
+	}
+
+	vs, err := OpenVersionsFile(path)
+	if err != nil {
+		return err
+	}
+
+	errs := examples.MultiErr{
+		setComposeServerVersion(vs.Server, ""),
+	}
+
+	for _, l := range langs.All() {
+		for _, d := range l.Dependencies {
+			version := dependencyVersion(l, d, vs)
+			if version == "" {
+				continue
+			}
+
+			// Update the file in each client directory for this language.
+			if d.Examples {
+				matches, err := filepath.Glob(filepath.Join(examplesDir, "*", "*", l.Name, d.File))
+				if err != nil {
+					errs.Append(fmt.Errorf("%s: %w", examplesDir, err))
+					continue
+				}
+				for _, m := range matches {
+					errs.Append(setDependency(d, version, filepath.Dir(m)))
+				}
+				continue
+			}
+
+			errs.Append(setDependency(d, version, filepath.Join(dockerDir, l.DockerDir())))
+		}
+	}
+
+	if errs.Empty() {
+		return nil
+	}
+	return &errs
+}
+
+// ReplaceVersions updates the versions declared in the files of dir, e.g. the
+// build directory of an image. If langs is nil, lang.Default is used.
+func ReplaceVersions(dir string, vs *Versions, langs *lang.Registry) error {
+	if langs == nil {
+		langs = lang.Default
+	}
+
+	errs := examples.MultiErr{
+		setComposeServerVersion(vs.Server, dir),
+	}
+
+	for _, l := range langs.All() {
+		for _, d := range l.Dependencies {
+			version := dependencyVersion(l, d, vs)
+			if version == "" {
+				continue
+			}
+			errs.Append(setDependency(d, version, dir))
+		}
+	}
+
+	if errs.Empty() {
+		return nil
+	}
+	return &errs
+}
+
+// Job runs an example with a server and client version.
+type Job struct {
+	Repo          string
+	Example       string
+	Client        string
+	ClientVersion string
+	ServerVersion string
+	Verbose       bool
+	// Defaults to lang.Default.
+	Languages *lang.Registry
+}
+
+func (j *Job) Run() error {
+	vs := &Versions{
+		Server: j.ServerVersion,
+		Clients: map[string]string{
+			j.Client: j.ClientVersion,
+		},
+	}
+
+	b := ImageBuilder{
+		Repo:      j.Repo,
+		Example:   j.Example,
+		Versions:  vs,
+		Verbose:   j.Verbose,
+		Languages: j.Languages,
+	}
+
+	image, err := b.Run()
+	if err != nil {
+		return err
+	}
+	defer RemoveImage(image)
+
+	stderr := bytes.NewBuffer(nil)
+	r := ComposeRunner{
+		Repo:      j.Repo,
+		Example:   j.Example,
+		Versions:  vs,
+		Verbose:   j.Verbose,
+		Languages: j.Languages,
+		Stderr:    stderr,
+		Stdout:    stderr,
+	}
+	err = r.Run(image)
+	if err != nil {
+		return fmt.Errorf("%w:\n%s", err, stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package site
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+// Names of the standard terminal colors, by their SGR offset.
+var ansiColors = [8]string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// ansiStyle is the graphic rendition of a run of terminal output.
+type ansiStyle struct {
+	bold, faint, italic, underline, inverse bool
+	// Colors, either the name of a standard color, e.g. red or bright-red,
+	// or a CSS color for the 256 and 24-bit colors.
+	fg, bg string
+}
+
+type ansiSpan struct {
+	style ansiStyle
+	text  string
+}
+
+// renderANSI renders terminal output, such as recorded with asciinema, as
+// HTML with the SGR sequences as styled spans, and returns it along with the
+// plain text. Other escape sequences, e.g. moving the cursor or hiding it,
+// are removed. A carriage return not followed by a newline starts the line
+// over, as progress output does.
+func renderANSI(s string) (template.HTML, string) {
+	var (
+		style ansiStyle
+		lines [][]ansiSpan
+		line  []ansiSpan
+		text  strings.Builder
+	)
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		if n := len(line); n > 0 && line[n-1].style == style {
+			line[n-1].text += text.String()
+		} else {
+			line = append(line, ansiSpan{style: style, text: text.String()})
+		}
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\n':
+			flush()
+			lines = append(lines, line)
+			line = nil
+		case c == '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				continue
+			}
+			text.Reset()
+			line = nil
+		case c == '\x1b':
+			flush()
+			i = skipEscape(s, i, &style)
+		case c == '\t' || c >= 0x20 && c != 0x7f:
+			text.WriteByte(c)
+		}
+	}
+	flush()
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+
+	var h, plain strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			h.WriteByte('\n')
+			plain.WriteByte('\n')
+		}
+		for _, span := range trimSpans(l) {
+			plain.WriteString(span.text)
+			span.style.writeHTML(&h, span.text)
+		}
+	}
+	if strings.HasSuffix(s, "\n") {
+		h.WriteByte('\n')
+		plain.WriteByte('\n')
+	}
+	return template.HTML(h.String()), plain.String()
+}
+
+// skipEscape returns the index of the last byte of the escape sequence
+// starting at i, applying it to the style if it is an SGR sequence.
+func skipEscape(s string, i int, style *ansiStyle) int {
+	if i+1 >= len(s) {
+		return i
+	}
+	switch s[i+1] {
+	case '[':
+		// Control sequence: parameter bytes, intermediate bytes and a
+		// final byte.
+		j := i + 2
+		for j < len(s) && s[j] >= 0x30 && s[j] <= 0x3f {
+			j++
+		}
+		params := s[i+2 : j]
+		for j < len(s) && s[j] >= 0x20 && s[j] <= 0x2f {
+			j++
+		}
+		if j >= len(s) {
+			return len(s) - 1
+		}
+		if s[j] == 'm' && j == i+2+len(params) {
+			style.apply(params)
+		}
+		return j
+	case ']':
+		// Operating system command, terminated by BEL or ST.
+		return skipString(s, i+2, true)
+	case 'P', 'X', '^', '_':
+		// Device control string, start of string, privacy message and
+		// application program command, terminated by ST.
+		return skipString(s, i+2, false)
+	default:
+		// nF escape sequence, e.g. ESC ( B: intermediate bytes and a final
+		// byte. Other sequences have just the final byte.
+		j := i + 1
+		for j < len(s) && s[j] >= 0x20 && s[j] <= 0x2f {
+			j++
+		}
+		if j >= len(s) {
+			return len(s) - 1
+		}
+		return j
+	}
+}
+
+// skipString returns the index of the last byte of the control string
+// whose content starts at i, terminated by ST or, if bel is set, BEL.
+func skipString(s string, i int, bel bool) int {
+	for j := i; j < len(s); j++ {
+		if bel && s[j] == '\a' {
+			return j
+		}
+		if s[j] == '\x1b' && j+1 < len(s) && s[j+1] == '\\' {
+			return j + 1
+		}
+	}
+	return len(s) - 1
+}
+
+// apply applies the parameters of an SGR sequence to the style. Private
+// sequences and unknown parameters are ignored.
+func (st *ansiStyle) apply(params string) {
+	if strings.ContainsAny(params, "<=>?") {
+		return
+	}
+	// Empty parameters are zero, e.g. ESC[m resets the style.
+	var codes []int
+	for _, p := range strings.Split(strings.ReplaceAll(params, ":", ";"), ";") {
+		n := 0
+		if p != "" {
+			var err error
+			if n, err = strconv.Atoi(p); err != nil {
+				return
+			}
+		}
+		codes = append(codes, n)
+	}
+
+	for k := 0; k < len(codes); k++ {
+		switch n := codes[k]; {
+		case n == 0:
+			*st = ansiStyle{}
+		case n == 1:
+			st.bold = true
+		case n == 2:
+			st.faint = true
+		case n == 3:
+			st.italic = true
+		case n == 4:
+			st.underline = true
+		case n == 7:
+			st.inverse = true
+		case n == 22:
+			st.bold, st.faint = false, false
+		case n == 23:
+			st.italic = false
+		case n == 24:
+			st.underline = false
+		case n == 27:
+			st.inverse = false
+		case n >= 30 && n <= 37:
+			st.fg = ansiColors[n-30]
+		case n == 39:
+			st.fg = ""
+		case n >= 40 && n <= 47:
+			st.bg = ansiColors[n-40]
+		case n == 49:
+			st.bg = ""
+		case n >= 90 && n <= 97:
+			st.fg = "bright-" + ansiColors[n-90]
+		case n >= 100 && n <= 107:
+			st.bg = "bright-" + ansiColors[n-100]
+		case n == 38 || n == 48:
+			c, used := extendedColor(codes[k+1:])
+			k += used
+			if n == 38 {
+				st.fg = c
+			} else {
+				st.bg = c
+			}
+		}
+	}
+}
+
+// extendedColor returns the 256 or 24-bit color of the parameters following
+// 38 or 48, and the number of parameters used.
+func extendedColor(codes []int) (string, int) {
+	switch {
+	case len(codes) >= 2 && codes[0] == 5:
+		n := codes[1]
+		switch {
+		case n < 0 || n > 255:
+			return "", 2
+		case n < 8:
+			return ansiColors[n], 2
+		case n < 16:
+			return "bright-" + ansiColors[n-8], 2
+		case n < 232:
+			// 6x6x6 color cube.
+			n -= 16
+			level := func(v int) int {
+				if v == 0 {
+					return 0
+				}
+				return 55 + 40*v
+			}
+			return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6)), 2
+		default:
+			v := 8 + 10*(n-232)
+			return fmt.Sprintf("#%02x%02x%02x", v, v, v), 2
+		}
+	case len(codes) >= 4 && codes[0] == 2:
+		clamp := func(v int) int {
+			if v < 0 {
+				return 0
+			}
+			if v > 255 {
+				return 255
+			}
+			return v
+		}
+		return fmt.Sprintf("#%02x%02x%02x", clamp(codes[1]), clamp(codes[2]), clamp(codes[3])), 4
+	}
+	return "", len(codes)
+}
+
+// writeHTML writes the escaped text, within a span if it is styled. The
+// standard colors are classes styled by main.css, e.g. ansi-red or
+// ansi-bg-bright-red.
+func (st ansiStyle) writeHTML(h *strings.Builder, text string) {
+	if st == (ansiStyle{}) {
+		h.WriteString(html.EscapeString(text))
+		return
+	}
+
+	var classes, css []string
+	fg, bg := st.fg, st.bg
+	if st.inverse {
+		fg, bg = bg, fg
+		if fg == "" || bg == "" {
+			classes = append(classes, "ansi-inverse")
+		}
+	}
+	for _, f := range []struct {
+		on   bool
+		name string
+	}{
+		{st.bold, "bold"},
+		{st.faint, "faint"},
+		{st.italic, "italic"},
+		{st.underline, "underline"},
+	} {
+		if f.on {
+			classes = append(classes, "ansi-"+f.name)
+		}
+	}
+	switch {
+	case strings.HasPrefix(fg, "#"):
+		css = append(css, "color:"+fg)
+	case fg != "":
+		classes = append(classes, "ansi-"+fg)
+	}
+	switch {
+	case strings.HasPrefix(bg, "#"):
+		css = append(css, "background-color:"+bg)
+	case bg != "":
+		classes = append(classes, "ansi-bg-"+bg)
+	}
+
+	h.WriteString("<span")
+	if len(classes) > 0 {
+		fmt.Fprintf(h, ` class="%s"`, strings.Join(classes, " "))
+	}
+	if len(css) > 0 {
+		fmt.Fprintf(h, ` style="%s"`, strings.Join(css, ";"))
+	}
+	h.WriteByte('>')
+	h.WriteString(html.EscapeString(text))
+	h.WriteString("</span>")
+}
+
+// trimSpans removes the trailing whitespace of a line, e.g. the padding of
+// progress output.
+func trimSpans(spans []ansiSpan) []ansiSpan {
+	for len(spans) > 0 {
+		last := &spans[len(spans)-1]
+		last.text = strings.TrimRight(last.text, " \t")
+		if last.text != "" {
+			break
+		}
+		spans = spans[:len(spans)-1]
+	}
+	return spans
+}
@@ -0,0 +1,77 @@
+package site
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		html  string
+		text  string
+	}{
+		{
+			name:  "plain",
+			input: "a < b\n",
+			html:  "a &lt; b\n",
+			text:  "a < b\n",
+		},
+		{
+			name:  "colors",
+			input: "\x1b[1;31mERR\x1b[0m done \x1b[33mwarn\x1b[39m\x1b[m\n",
+			html:  `<span class="ansi-bold ansi-red">ERR</span> done <span class="ansi-yellow">warn</span>` + "\n",
+			text:  "ERR done warn\n",
+		},
+		{
+			name:  "extended colors",
+			input: "\x1b[38;5;208mx\x1b[48;2;1;2;3my\x1b[0m",
+			html:  `<span style="color:#ff8700">x</span><span style="color:#ff8700;background-color:#010203">y</span>`,
+			text:  "xy",
+		},
+		{
+			name:  "cursor movement",
+			input: "\x1b[1A\x1b[1B\x1b[0G\x1b[?25l[+] Building 0.0s     \r\n\x1b[?25hready\r\n",
+			html:  "[+] Building 0.0s\nready\n",
+			text:  "[+] Building 0.0s\nready\n",
+		},
+		{
+			name:  "carriage return",
+			input: "10%\r50%\r\x1b[32m100%\x1b[0m\n",
+			html:  `<span class="ansi-green">100%</span>` + "\n",
+			text:  "100%\n",
+		},
+		{
+			name:  "character set",
+			input: "\x1b[1mok\x1b(B\x1b[m done\x1b7\x1b8\n",
+			html:  `<span class="ansi-bold">ok</span> done` + "\n",
+			text:  "ok done\n",
+		},
+		{
+			name:  "control strings",
+			input: "a\x1bP1$r0m\x1b\\b\x1b_app\acmd\x1b\\c",
+			html:  "abc",
+			text:  "abc",
+		},
+		{
+			name:  "title and truncated sequence",
+			input: "\x1b]0;title\aok\x1b[3",
+			html:  "ok",
+			text:  "ok",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			html, text := renderANSI(test.input)
+			if diff := cmp.Diff(test.html, string(html)); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(test.text, text); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package site
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/internal/fsutil"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+// Builder parses the examples and renders the site along with the static
+// files.
+type Builder struct {
+	// Directory containing the examples.
+	Source string
+	// Directory containing the static files copied to the output.
+	Static string
+	// Directory the site is written to.
+	Output string
+	// If true, the existing contents of the output directory are removed and
+	// all pages are rendered.
+	Clean bool
+	// Languages used to parse and render the examples. Defaults to
+	// lang.Default.
+	Languages *lang.Registry
+	// Logger for missing output files. Defaults to the standard logger.
+	Logger *log.Logger
+	// Settings of the site such as the base URL and title. Defaults to
+	// DefaultConfig.
+	Config *Config
+	// Directory of templates overriding the embedded ones by name, e.g.
+	// client.html, or adding partials. Optional.
+	Theme string
+
+	// Set once the missing git history is logged, so repeated builds do not
+	// log it again.
+	noHistory bool
+}
+
+func (b *Builder) generator() (*generator, error) {
+	g, err := newGenerator(b.Languages, b.Logger, b.Config)
+	if err != nil {
+		return nil, err
+	}
+	if b.Theme != "" {
+		theme, err := loadTheme(b.Theme)
+		if err != nil {
+			return nil, err
+		}
+		g.theme = theme
+	}
+	if f := g.config.Social.CardFont; f != "" && b.Static != "" {
+		font, err := loadFont(filepath.Join(b.Static, f))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			g.logger.Printf("%s: card font not found, the social cards are not generated", filepath.Join(b.Static, f))
+		case err != nil:
+			return nil, fmt.Errorf("config: card_font: %w", err)
+		default:
+			g.cardFont = font
+		}
+	}
+	return g, nil
+}
+
+// newGenerator returns a generator with the defaults for the unset
+// arguments.
+func newGenerator(langs *lang.Registry, logger *log.Logger, config *Config) (*generator, error) {
+	g := generator{
+		langs:  langs,
+		logger: logger,
+		config: config,
+	}
+	if g.langs == nil {
+		g.langs = lang.Default
+	}
+	if g.logger == nil {
+		g.logger = log.Default()
+	}
+	if g.config == nil {
+		g.config = DefaultConfig()
+	}
+	if len(g.config.Languages) > 0 {
+		langs, err := g.langs.WithOrder(g.config.Languages)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		g.langs = langs
+	}
+	return &g, nil
+}
+
+// parse parses the examples of the source directory with the languages of
+// the generator.
+func (g *generator) parse(source string) (*examples.Root, error) {
+	p := examples.Parser{
+		Languages: g.langs,
+		Logger:    g.logger,
+	}
+	root, err := p.Parse(source)
+	if err != nil {
+		var errs *examples.MultiErr
+		if errors.As(err, &errs) {
+			return nil, fmt.Errorf("%d error(s) parsing examples:\n%w", len(*errs), err)
+		}
+		return nil, err
+	}
+	return root, nil
+}
+
+// loadHistory loads the dates of the examples from the git history. They
+// are optional, e.g. the sources may not be within a git repository.
+func (b *Builder) loadHistory(g *generator, root *examples.Root) {
+	var err error
+	g.history, err = gitHistory(root.Path)
+	if err != nil && !b.noHistory {
+		g.logger.Printf("%s: no git history, only the added dates of the examples are used: %s", root.Path, err)
+	}
+	b.noHistory = err != nil
+}
+
+// Run parses the examples and renders the site to the output directory. Only
+// the files whose inputs changed since the last build are written. If Clean
+// is true or there is no build cache, any existing contents of the output
+// directory are removed first.
+func (b *Builder) Run() error {
+	source, static, output := b.Source, b.Static, b.Output
+	g, err := b.generator()
+	if err != nil {
+		return err
+	}
+
+	root, err := g.parse(source)
+	if err != nil {
+		return err
+	}
+	b.loadHistory(g, root)
+
+	cache, ok, err := loadBuildCache(output, g.langs.All(), g.config, g.theme)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(output); os.IsNotExist(err) {
+		if err := os.MkdirAll(output, 0755); err != nil {
+			return err
+		}
+	} else if b.Clean || !ok {
+		entries, err := os.ReadDir(output)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := os.RemoveAll(filepath.Join(output, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	entries, err := fs.ReadDir(os.DirFS(static), ".")
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		data, err := ioutil.ReadFile(filepath.Join(static, e.Name()))
+		if err != nil {
+			return err
+		}
+		fresh, err := cache.Fresh(e.Name(), data)
+		if err != nil {
+			return err
+		}
+		if fresh {
+			continue
+		}
+		err = fsutil.CreateFile(filepath.Join(output, e.Name()), data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := g.generateDocs(root, cache); err != nil {
+		return err
+	}
+
+	if err := cache.Prune(); err != nil {
+		return err
+	}
+	return cache.Save()
+}
+
+// Check builds the site twice into temporary directories and returns an
+// error listing the files that differ between the builds, if any. The output
+// directory is not written.
+func (b *Builder) Check() error {
+	tmp, err := os.MkdirTemp("", "nbe-check-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	x, y := *b, *b
+	x.Output = filepath.Join(tmp, "a")
+	y.Output = filepath.Join(tmp, "b")
+	x.Clean = true
+	y.Clean = true
+
+	if err := x.Run(); err != nil {
+		return err
+	}
+	if err := y.Run(); err != nil {
+		return err
+	}
+
+	diffs, err := diffDirs(x.Output, y.Output)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d file(s) differ between builds:", len(diffs))
+	for _, d := range diffs {
+		fmt.Fprintf(&buf, "\n\t%s", d)
+	}
+	return errors.New(buf.String())
+}
+
+// diffDirs returns the sorted relative paths of the files which differ in
+// content, or only exist in one of the two directories.
+func diffDirs(a, b string) ([]string, error) {
+	read := func(dir string) (map[string][]byte, error) {
+		files := make(map[string][]byte)
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files[rel], err = os.ReadFile(path)
+			return err
+		})
+		return files, err
+	}
+
+	fa, err := read(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := read(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []string
+	for p, x := range fa {
+		if y, ok := fb[p]; !ok || !bytes.Equal(x, y) {
+			diffs = append(diffs, p)
+		}
+	}
+	for p := range fb {
+		if _, ok := fa[p]; !ok {
+			diffs = append(diffs, p)
+		}
+	}
+	sort.Strings(diffs)
+	return diffs, nil
+}
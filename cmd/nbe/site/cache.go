@@ -0,0 +1,257 @@
+package site
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/internal/fsutil"
+)
+
+// siteOutput receives the files of the site rendered by the generator.
+type siteOutput interface {
+	// Fresh records that the file at path is produced from the inputs and
+	// returns true if it does not need to be written.
+	Fresh(path string, inputs ...any) (bool, error)
+	// Write writes the file at path, relative to the root of the site.
+	Write(path string, b []byte) error
+}
+
+// partialOutput is implemented by outputs which need only some of the files
+// of the site, e.g. when rendering a single page on demand.
+type partialOutput interface {
+	// Skip returns true if the file at path is not needed, in which case
+	// its inputs need not be computed. The file is still part of the site.
+	Skip(path string) bool
+}
+
+// skipFiles returns true if the output needs none of the files at the
+// paths.
+func skipFiles(out siteOutput, paths ...string) bool {
+	po, ok := out.(partialOutput)
+	if !ok {
+		return false
+	}
+	skip := true
+	for _, p := range paths {
+		if !po.Skip(p) {
+			skip = false
+		}
+	}
+	return skip
+}
+
+// Suffix of the name of the build cache file. The file is kept next to the
+// output directory, e.g. .html.nbe-cache.json for html, so it is not
+// published along with the site.
+const buildCacheSuffix = ".nbe-cache.json"
+
+// Version of the build cache format. Changing it invalidates existing caches.
+const buildCacheVersion = 1
+
+// buildCache tracks the files written to the output directory along with a
+// hash of the inputs each was produced from, e.g. the meta.yaml contents,
+// source files and output of an example. Files whose inputs are unchanged
+// since the last build are not rendered again and files no longer produced
+// by the build are removed.
+type buildCache struct {
+	dir string
+	// Path of the cache file.
+	file string
+	// Hash of the inputs shared by all files, i.e. the version of nbe, the
+	// embedded templates and the settings, e.g. the language registry.
+	global string
+
+	// Keys of the files from the previous build.
+	prev map[string]string
+	// Keys of the files produced by this build.
+	next map[string]string
+}
+
+type buildCacheData struct {
+	Version int               `json:"version"`
+	Files   map[string]string `json:"files"`
+}
+
+// loadBuildCache loads the cache of the output directory. If the cache does
+// not exist or is not valid, an empty cache is returned and ok is false. A
+// change to any of the settings, e.g. the languages, invalidates all files.
+func loadBuildCache(dir string, settings ...any) (c *buildCache, ok bool, err error) {
+	dir = filepath.Clean(dir)
+	c = &buildCache{
+		dir:  dir,
+		file: filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+buildCacheSuffix),
+		prev: make(map[string]string),
+		next: make(map[string]string),
+	}
+
+	c.global, err = globalCacheKey(settings...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	b, err := os.ReadFile(c.file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, false, nil
+		}
+		return nil, false, err
+	}
+
+	var d buildCacheData
+	if err := json.Unmarshal(b, &d); err != nil || d.Version != buildCacheVersion {
+		return c, false, nil
+	}
+	if d.Files != nil {
+		c.prev = d.Files
+	}
+	return c, true, nil
+}
+
+// templates are the embedded templates, part of the global cache key.
+//
+//go:embed tmpl
+var templates embed.FS
+
+func globalCacheKey(settings ...any) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", buildCacheVersion)
+
+	// Any change to nbe itself, e.g. rendering or the versions of its
+	// dependencies, invalidates the cache. Builds from a modified checkout
+	// share the revision, so the templates are hashed as well.
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", errors.New("cache key: no build info")
+	}
+	fmt.Fprintf(h, "%s %s %s\n", info.Main.Path, info.Main.Version, info.Main.Sum)
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" || s.Key == "vcs.modified" {
+			fmt.Fprintf(h, "%s=%s\n", s.Key, s.Value)
+		}
+	}
+	for _, d := range info.Deps {
+		fmt.Fprintf(h, "%s %s %s\n", d.Path, d.Version, d.Sum)
+	}
+	err := fs.WalkDir(templates, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := templates.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s %d\n", path, len(b))
+		h.Write(b)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := json.NewEncoder(h)
+	for _, x := range settings {
+		if err := enc.Encode(x); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Fresh records that the file at path, relative to the output directory, is
+// produced from the inputs. It returns true if the file exists and was
+// produced from the same inputs by the previous build, in which case it does
+// not need to be written again. A nil cache is never fresh.
+func (c *buildCache) Fresh(path string, inputs ...any) (bool, error) {
+	if c == nil {
+		return false, nil
+	}
+
+	key, err := fileCacheKey(c.global, path, inputs...)
+	if err != nil {
+		return false, err
+	}
+
+	path = filepath.ToSlash(path)
+	c.next[path] = key
+
+	if c.prev[path] != key {
+		return false, nil
+	}
+	_, err = os.Stat(filepath.Join(c.dir, path))
+	return err == nil, nil
+}
+
+// fileCacheKey returns the hash of the inputs of a file, along with the
+// global key.
+func fileCacheKey(global, path string, inputs ...any) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(global))
+	enc := json.NewEncoder(h)
+	for _, x := range inputs {
+		if err := enc.Encode(x); err != nil {
+			return "", fmt.Errorf("%s: cache key: %w", path, err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Write writes the file at path to the output directory.
+func (c *buildCache) Write(path string, b []byte) error {
+	return fsutil.CreateFile(filepath.Join(c.dir, path), b)
+}
+
+// Prune removes the files produced by the previous build, but not this one,
+// along with any directories left empty.
+func (c *buildCache) Prune() error {
+	if c == nil {
+		return nil
+	}
+
+	var stale []string
+	for p := range c.prev {
+		if _, ok := c.next[p]; !ok {
+			stale = append(stale, p)
+		}
+	}
+	sort.Strings(stale)
+
+	for _, p := range stale {
+		path := filepath.Join(c.dir, filepath.FromSlash(p))
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		// Remove empty parent directories up to the output directory.
+		for d := filepath.Dir(path); d != c.dir && d != "."; d = filepath.Dir(d) {
+			if os.Remove(d) != nil {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// Save writes the files produced by this build to the cache file.
+func (c *buildCache) Save() error {
+	if c == nil {
+		return nil
+	}
+	b, err := json.MarshalIndent(&buildCacheData{
+		Version: buildCacheVersion,
+		Files:   c.next,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.CreateFile(c.file, b)
+}
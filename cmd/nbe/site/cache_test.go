@@ -0,0 +1,99 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/internal/fsutil"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+func checkEqual[T comparable](t *testing.T, a, b T) {
+	t.Helper()
+	if a != b {
+		t.Error("not equal")
+	}
+}
+
+// writeTree writes the files, keyed by their path relative to dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := fsutil.CreateFile(filepath.Join(dir, name), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// pubSubTree returns the files of a site with a single example,
+// messaging/pub-sub, with a Go client. The files of extra are added or
+// replace those of the example.
+func pubSubTree(extra map[string]string) map[string]string {
+	files := map[string]string{
+		"examples/meta.yaml":                    "categories: [messaging]\n",
+		"examples/messaging/pub-sub/meta.yaml":  "description: Publish and subscribe.\n",
+		"examples/messaging/pub-sub/go/main.go": "package main\n\n// Connect.\nfunc main() {}\n",
+		"static/main.css":                       "",
+	}
+	for name, content := range extra {
+		files[name] = content
+	}
+	return files
+}
+
+func TestBuildCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "html")
+
+	c, ok, err := loadBuildCache(dir, lang.Default.All(), DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, ok, false)
+
+	for _, p := range []string{"a/index.html", "b/index.html"} {
+		fresh, err := c.Fresh(p, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkEqual(t, fresh, false)
+		if err := fsutil.CreateFile(filepath.Join(dir, p), []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cache file is kept out of the output directory.
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), ".html.nbe-cache.json")); err != nil {
+		t.Error(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(entries), 2)
+
+	c, ok, err = loadBuildCache(dir, lang.Default.All(), DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, ok, true)
+
+	// Unchanged inputs are fresh, changed inputs are not.
+	fresh, _ := c.Fresh("a/index.html", "a/index.html")
+	checkEqual(t, fresh, true)
+	fresh, _ = c.Fresh("a/index.html", "changed")
+	checkEqual(t, fresh, false)
+
+	// Files not produced by this build are removed.
+	if err := c.Prune(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(filepath.Join(dir, "b"))
+	checkEqual(t, os.IsNotExist(err), true)
+	if _, err := os.Stat(filepath.Join(dir, "a/index.html")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,282 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// Size of the social cards, the recommended size of Open Graph images.
+const (
+	cardWidth  = 1200
+	cardHeight = 630
+)
+
+// Name of the social card image within the directory of a page.
+const cardFile = "card.png"
+
+var (
+	cardBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	cardText       = color.RGBA{0x1c, 0x1c, 0x1c, 0xff}
+	natsBlue       = color.RGBA{0x27, 0xaa, 0xe1, 0xff}
+	natsGreen      = color.RGBA{0x34, 0xa5, 0x74, 0xff}
+	natsLime       = color.RGBA{0x8d, 0xc6, 0x3f, 0xff}
+	natsNavy       = color.RGBA{0x37, 0x5c, 0x93, 0xff}
+)
+
+// Number of shades of each color of the card in its palette, from the
+// background to the color.
+const cardShades = 50
+
+// cardPalette is the background followed by the shades of the colors of the
+// card blended with it, as drawn by the anti-aliased edges of the text and
+// logo. A paletted card is about a third of the size of an RGB one.
+var cardPalette = func() color.Palette {
+	p := color.Palette{cardBackground}
+	for _, c := range []color.RGBA{cardText, natsBlue, natsGreen, natsLime, natsNavy} {
+		for i := 1; i <= cardShades; i++ {
+			a := float64(i) / cardShades
+			blend := func(x, y uint8) uint8 {
+				return uint8(float64(x)*a + float64(y)*(1-a) + 0.5)
+			}
+			p = append(p, color.RGBA{blend(c.R, cardBackground.R), blend(c.G, cardBackground.G), blend(c.B, cardBackground.B), 0xff})
+		}
+	}
+	return p
+}()
+
+// natsLogo is the NATS logo of nats.svg, as polygons in its view box with
+// their colors.
+var natsLogo = []struct {
+	color  color.RGBA
+	points []float64
+}{
+	{natsGreen, []float64{142.8, 5.3, 277.5, 5.3, 277.5, 114.5, 142.8, 114.5}},
+	{natsBlue, []float64{8.1, 5.3, 142.8, 5.3, 142.8, 114.5, 8.1, 114.5}},
+	{natsLime, []float64{142.8, 114.6, 277.5, 114.6, 277.5, 223.8, 142.8, 223.8}},
+	{natsNavy, []float64{8.1, 114.6, 142.8, 114.6, 142.8, 223.8, 8.1, 223.8}},
+	{natsLime, []float64{123, 223.2, 188.9, 284.2, 188.9, 223.2}},
+	{natsNavy, []float64{142.8, 223.2, 143.5, 242.4, 122.4, 222.7}},
+	{color.RGBA{0xff, 0xff, 0xff, 0xff}, []float64{
+		198.6, 146.5, 198.6, 56.1, 230.8, 56.1, 230.8, 173, 182, 173, 83.5, 81,
+		83.5, 173.1, 51.2, 173.1, 51.2, 56.1, 101.7, 56.1,
+	}},
+}
+
+// socialCard is the text of the preview image of a page.
+type socialCard struct {
+	Title    string
+	Category string
+	// Label of the language of a client page, empty for example pages.
+	Language string
+	Site     string
+}
+
+// pageImage is the preview image of a page for link previews on social
+// media.
+type pageImage struct {
+	CanonicalImageURL template.URL
+	ImageAlt          string
+	// True if the image is the generated card of the page, of the size
+	// cardWidth by cardHeight.
+	Card bool
+}
+
+// cardQueue collects the cards which are not fresh, so they are rendered in
+// parallel once all pages are generated.
+type cardQueue struct {
+	paths []string
+	cards []*socialCard
+}
+
+// pageImage queues the card of the page directory, unless it is fresh, and
+// returns it as the image of the page. If cards are not generated, the image
+// of the config is returned.
+func (g *generator) pageImage(dir string, card *socialCard, out siteOutput, q *cardQueue) (pageImage, error) {
+	if g.cardFont == nil {
+		return pageImage{
+			CanonicalImageURL: template.URL(g.assetURL(g.config.Social.Image)),
+			ImageAlt:          g.config.Social.ImageAlt,
+		}, nil
+	}
+
+	path := filepath.Join(dir, cardFile)
+	fresh, err := out.Fresh(path, card, g.cardFont.sum)
+	if err != nil {
+		return pageImage{}, err
+	}
+	if !fresh {
+		q.paths = append(q.paths, path)
+		q.cards = append(q.cards, card)
+	}
+
+	alt := card.Title
+	if card.Language != "" {
+		alt += " (" + card.Language + ")"
+	}
+	return pageImage{
+		CanonicalImageURL: template.URL(g.pageURL(path)),
+		ImageAlt:          alt + " - " + card.Site,
+		Card:              true,
+	}, nil
+}
+
+// renderCards renders the queued cards with a worker per CPU and writes them
+// in the order they were queued.
+func (g *generator) renderCards(q *cardQueue, out siteOutput) error {
+	var (
+		wg     sync.WaitGroup
+		images = make([][]byte, len(q.cards))
+		errs   = make([]error, len(q.cards))
+		next   = make(chan int)
+	)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range next {
+				images[j], errs[j] = g.renderCard(q.cards[j])
+			}
+		}()
+	}
+	for j := range q.cards {
+		next <- j
+	}
+	close(next)
+	wg.Wait()
+
+	for j, path := range q.paths {
+		if errs[j] != nil {
+			return fmt.Errorf("%s: %w", path, errs[j])
+		}
+		if err := out.Write(path, images[j]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderCard returns the PNG image of the card, with the NATS logo and the
+// category at the top, the title in the middle and the language and site
+// title at the bottom, above a strip of the NATS colors.
+func (g *generator) renderCard(card *socialCard) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(cardBackground), image.Point{}, draw.Src)
+
+	const margin = 80
+	logo := 120.0
+	drawLogo(img, margin, 60, logo)
+
+	// Faces of the sizes of the text, from the largest size of the title
+	// down to the size of the category and site title.
+	faces := make(map[float64]font.Face)
+	for size := 112.0; size >= 64; size -= 8 {
+		face, err := g.cardFont.face(size)
+		if err != nil {
+			return nil, err
+		}
+		faces[size] = face
+	}
+	text := func(s string, x, y float64, face font.Face, c color.Color) {
+		d := font.Drawer{
+			Dst:  img,
+			Src:  image.NewUniform(c),
+			Face: face,
+			Dot:  fixed.Point26_6{X: toFixed(x), Y: toFixed(y)},
+		}
+		d.DrawString(s)
+	}
+
+	text(card.Category, margin+logo+40, 60+logo/2+22, faces[64], natsNavy)
+
+	// The title is shrunk to fit on two lines, down to a minimum size, and
+	// cut short after three.
+	width := float64(cardWidth - 2*margin)
+	size := 112.0
+	lines := wrap(faces[size], card.Title, width)
+	for len(lines) > 2 && size > 72 {
+		size -= 8
+		lines = wrap(faces[size], card.Title, width)
+	}
+	if len(lines) > 3 {
+		lines = lines[:3]
+		lines[2] = ellipsis(faces[size], lines[2], width)
+	}
+	lineHeight := size * 1.05
+	top := 230 + (3*112*1.05-float64(len(lines))*lineHeight)/2
+	for i, l := range lines {
+		text(l, margin, top+float64(i)*lineHeight+size*0.7, faces[size], cardText)
+	}
+
+	const bottom = cardHeight - 60
+	text(card.Language, margin, bottom, faces[72], natsBlue)
+	text(card.Site, cardWidth-margin-measure(faces[64], card.Site), bottom, faces[64], natsGreen)
+
+	strip := cardWidth / 4
+	for i, c := range []color.RGBA{natsBlue, natsGreen, natsNavy, natsLime} {
+		rect := image.Rect(i*strip, cardHeight-16, (i+1)*strip, cardHeight)
+		draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.DefaultCompression}
+	if err := enc.Encode(&buf, paletted(img, cardPalette)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// paletted returns the image with its colors replaced by the nearest ones of
+// the palette. Each distinct color is looked up once.
+func paletted(img *image.RGBA, p color.Palette) *image.Paletted {
+	b := img.Bounds()
+	dst := image.NewPaletted(b, p)
+	index := make(map[color.RGBA]uint8)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			i, ok := index[c]
+			if !ok {
+				i = uint8(p.Index(c))
+				index[c] = i
+			}
+			dst.SetColorIndex(x, y, i)
+		}
+	}
+	return dst
+}
+
+// drawLogo draws the NATS logo with its top left corner at x and y, scaled
+// to the size.
+func drawLogo(img draw.Image, x, y, size float64) {
+	// View box of nats.svg.
+	const minX, minY, boxSize = 2.42, -0.33, 289.9
+	s := size / boxSize
+	b := img.Bounds()
+	r := vector.NewRasterizer(b.Dx(), b.Dy())
+	for _, p := range natsLogo {
+		r.Reset(b.Dx(), b.Dy())
+		for i := 0; i < len(p.points); i += 2 {
+			px := float32(x + (p.points[i]-minX)*s)
+			py := float32(y + (p.points[i+1]-minY)*s)
+			if i == 0 {
+				r.MoveTo(px, py)
+			} else {
+				r.LineTo(px, py)
+			}
+		}
+		r.ClosePath()
+		r.Draw(img, b, image.NewUniform(p.color), image.Point{})
+	}
+}
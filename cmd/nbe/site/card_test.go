@@ -0,0 +1,103 @@
+package site
+
+import (
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWrapText(t *testing.T) {
+	f, err := loadFont("../../../static/Cookie-Regular.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := f.face(64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	width := measure(face, "Publish and")
+	lines := wrap(face, "Publish and Subscribe", width)
+	checkEqual(t, len(lines), 2)
+	checkEqual(t, lines[0], "Publish and")
+	checkEqual(t, lines[1], "Subscribe")
+
+	s := ellipsis(face, "Publish and Subscribe", width)
+	checkEqual(t, strings.HasSuffix(s, "…"), true)
+	checkEqual(t, measure(face, s) <= width, true)
+}
+
+func TestSocialCards(t *testing.T) {
+	font, err := os.ReadFile("../../../static/Cookie-Regular.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeTree(t, dir, pubSubTree(map[string]string{
+		"examples/messaging/pub-sub/meta.yaml": "title: Core Publish-Subscribe\ndescription: |\n  Publish and **subscribe**.\n\n  More details.\n",
+		"static/Cookie-Regular.ttf":            string(font),
+	}))
+
+	b := Builder{
+		Source: filepath.Join(dir, "examples"),
+		Static: filepath.Join(dir, "static"),
+		Output: filepath.Join(dir, "html"),
+	}
+	if err := b.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	client := filepath.Join(b.Output, b.Source, "messaging/pub-sub/go")
+	for _, p := range []string{client, filepath.Dir(client)} {
+		f, err := os.Open(filepath.Join(p, cardFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := png.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkEqual(t, config.Width, cardWidth)
+		checkEqual(t, config.Height, cardHeight)
+		_, ok := config.ColorModel.(color.Palette)
+		checkEqual(t, ok, true)
+	}
+
+	page, err := os.ReadFile(filepath.Join(client, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := DefaultBaseURL + filepath.ToSlash(filepath.Join(b.Source, "messaging/pub-sub/go", cardFile))
+	checkEqual(t, strings.Contains(string(page), `<meta property="og:image" content="`+url+`" />`), true)
+	checkEqual(t, strings.Contains(string(page), `<meta name="twitter:image" content="`+url+`" />`), true)
+	checkEqual(t, strings.Contains(string(page), `content="Core Publish-Subscribe (Go) - NATS by Example"`), true)
+	checkEqual(t, strings.Contains(string(page), `<meta name="twitter:card" content="summary_large_image" />`), true)
+	checkEqual(t, strings.Contains(string(page), `<meta name="twitter:description" content="Publish and subscribe." />`), true)
+
+	page, err = os.ReadFile(filepath.Join(filepath.Dir(client), "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, strings.Contains(string(page), `<meta name="twitter:description" content="Publish and subscribe." />`), true)
+
+	// Without the font, the image of the config is used.
+	if err := os.Remove(filepath.Join(dir, "static/Cookie-Regular.ttf")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(client, cardFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected the card to be removed, got %v", err)
+	}
+	page, err = os.ReadFile(filepath.Join(client, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, strings.Contains(string(page), `<meta property="og:image" content="`+DefaultBaseURL+`/nbe-twitter.png" />`), true)
+}
@@ -0,0 +1,282 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+)
+
+// Directory of the compare pages within an example.
+const compareDir = "compare"
+
+// Minimum similarity of the comments of two sections for them to be shown
+// side by side.
+const compareThreshold = 0.3
+
+type compareData struct {
+	PageTitle     string
+	CanonicalURL  template.URL
+	CategoryTitle string
+	CategoryPath  string
+	ExampleTitle  string
+	ExamplePath   string
+	Left          *LanguageLink
+	Right         *LanguageLink
+	Rows          []*compareRow
+}
+
+// compareRow is a pair of aligned sections, either may be nil if the other
+// client has no matching section.
+type compareRow struct {
+	Left  *compareSection
+	Right *compareSection
+}
+
+// compareSection is a comment of the main file of a client along with the
+// code following it.
+type compareSection struct {
+	Comment *RenderedBlock
+	Code    []*RenderedBlock
+
+	// Words of the comment used to align the sections.
+	words map[string]bool
+}
+
+// comparePath returns the path of the page comparing two clients of an
+// example, e.g. examples/kv/intro/compare/go..rust.
+func comparePath(e *examples.Example, a, b string) string {
+	return path.Join(e.Path, compareDir, a+".."+b)
+}
+
+// compareLinks returns the links to the compare pages of the client with each
+// of the other clients, the clients are ordered as the language tabs.
+func (g *generator) compareLinks(e *examples.Example, clients []*examples.Client, client *examples.Client) []*Link {
+	var links []*Link
+	before := true
+	for _, c := range clients {
+		if c == client {
+			before = false
+			continue
+		}
+		p := comparePath(e, client.Name, c.Name)
+		if before {
+			p = comparePath(e, c.Name, client.Name)
+		}
+		links = append(links, &Link{
+			Label: g.langs.Label(c.Name),
+			Path:  p,
+		})
+	}
+	return links
+}
+
+// generateCompare writes a compare page for each pair of clients of the
+// example, keyed by the client names as clients of different names may share
+// a language, e.g. those falling back to shell. The main files of the
+// clients are split into sections which are aligned by the prose of their
+// comments.
+func (g *generator) generateCompare(c *examples.Category, e *examples.Example, clients []*examples.Client, out siteOutput, ct *template.Template) error {
+	sections := make(map[string][]*compareSection)
+	clientSections := func(i *examples.Client) ([]*compareSection, error) {
+		if s, ok := sections[i.Name]; ok {
+			return s, nil
+		}
+		s, err := g.compareSections(i)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", i.Path, err)
+		}
+		sections[i.Name] = s
+		return s, nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	for x, a := range clients {
+		for _, b := range clients[x+1:] {
+			cd := compareData{
+				CategoryTitle: c.Title,
+				CategoryPath:  c.Path,
+				ExampleTitle:  e.Title,
+				ExamplePath:   e.Path,
+				Left: &LanguageLink{
+					Name:  a.Name,
+					Label: g.langs.Label(a.Name),
+					Path:  a.Path,
+				},
+				Right: &LanguageLink{
+					Name:  b.Name,
+					Label: g.langs.Label(b.Name),
+					Path:  b.Path,
+				},
+			}
+			cd.PageTitle = g.config.pageTitle("%s (%s vs %s)", e.Title, cd.Left.Label, cd.Right.Label)
+
+			// The rows are derived from the sources of the main files.
+			p := comparePath(e, a.Name, b.Name)
+			cd.CanonicalURL = template.URL(g.pageURL(p))
+			page := filepath.Join(p, "index.html")
+			fresh, err := out.Fresh(page, &cd, a.Source, b.Source)
+			if err != nil {
+				return err
+			}
+			if fresh {
+				continue
+			}
+
+			left, err := clientSections(a)
+			if err != nil {
+				return err
+			}
+			right, err := clientSections(b)
+			if err != nil {
+				return err
+			}
+			cd.Rows = alignSections(left, right)
+
+			buf.Reset()
+			if err := ct.Execute(buf, &cd); err != nil {
+				return err
+			}
+			if err := out.Write(page, buf.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+var wordRe = regexp.MustCompile(`[a-z0-9]{3,}`)
+
+// compareSections splits the visible blocks of the main file of the client
+// into sections, each starting with a comment. Code preceding the first
+// comment is a section on its own.
+func (g *generator) compareSections(c *examples.Client) ([]*compareSection, error) {
+	fid := fileID(c.MainFile)
+	file := filepath.Join(c.Path, c.MainFile)
+
+	var (
+		sections []*compareSection
+		section  *compareSection
+	)
+	for _, b := range c.Blocks {
+		if b.Hidden {
+			continue
+		}
+		switch b.Type {
+		case examples.SingleLineCommentBlock, examples.MultiLineCommentBlock:
+			rb, err := g.renderBlock(c.Language, fid, file, b)
+			if err != nil {
+				return nil, err
+			}
+			text, _ := g.commentText(c.Language, b)
+			words := make(map[string]bool)
+			for _, w := range wordRe.FindAllString(strings.ToLower(plainText(text)), -1) {
+				words[w] = true
+			}
+			section = &compareSection{
+				Comment: rb,
+				words:   words,
+			}
+			sections = append(sections, section)
+
+		case examples.CodeBlock:
+			rb, err := g.renderBlock(c.Language, fid, file, b)
+			if err != nil {
+				return nil, err
+			}
+			if section == nil {
+				section = &compareSection{}
+				sections = append(sections, section)
+			}
+			section.Code = append(section.Code, rb)
+		}
+	}
+	return sections, nil
+}
+
+// similarity returns the Jaccard index of the words of the comments of two
+// sections. Two sections without a comment, i.e. the code preceding the first
+// comment, are considered similar.
+func similarity(a, b *compareSection) float64 {
+	if a.Comment == nil || b.Comment == nil {
+		if a.Comment == nil && b.Comment == nil {
+			return 1
+		}
+		return 0
+	}
+
+	var common int
+	for w := range a.words {
+		if b.words[w] {
+			common++
+		}
+	}
+	union := len(a.words) + len(b.words) - common
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+// alignSections aligns the sections of two clients, keeping their order, so
+// the total similarity of the sections shown side by side is the highest.
+// Sections without a counterpart get a row of their own.
+func alignSections(left, right []*compareSection) []*compareRow {
+	n, m := len(left), len(right)
+
+	sim := make([][]float64, n)
+	for i := range sim {
+		sim[i] = make([]float64, m)
+		for j := range sim[i] {
+			sim[i][j] = similarity(left[i], right[j])
+		}
+	}
+
+	// score[i][j] is the best alignment of left[i:] and right[j:].
+	score := make([][]float64, n+1)
+	for i := range score {
+		score[i] = make([]float64, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			s := score[i+1][j]
+			if score[i][j+1] > s {
+				s = score[i][j+1]
+			}
+			if sim[i][j] >= compareThreshold && score[i+1][j+1]+sim[i][j] > s {
+				s = score[i+1][j+1] + sim[i][j]
+			}
+			score[i][j] = s
+		}
+	}
+
+	var rows []*compareRow
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case sim[i][j] >= compareThreshold && score[i][j] == score[i+1][j+1]+sim[i][j]:
+			rows = append(rows, &compareRow{Left: left[i], Right: right[j]})
+			i++
+			j++
+		case score[i][j] == score[i+1][j]:
+			rows = append(rows, &compareRow{Left: left[i]})
+			i++
+		default:
+			rows = append(rows, &compareRow{Right: right[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		rows = append(rows, &compareRow{Left: left[i]})
+	}
+	for ; j < m; j++ {
+		rows = append(rows, &compareRow{Right: right[j]})
+	}
+	return rows
+}
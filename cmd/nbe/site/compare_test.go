@@ -0,0 +1,84 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAlignSections(t *testing.T) {
+	section := func(words ...string) *compareSection {
+		s := compareSection{words: make(map[string]bool)}
+		if len(words) > 0 {
+			s.Comment = &RenderedBlock{Type: "comment"}
+		}
+		for _, w := range words {
+			s.words[w] = true
+		}
+		return &s
+	}
+
+	var (
+		setup   = section()
+		connect = section("connect", "server")
+		publish = section("publish", "message", "subject")
+		drain   = section("drain", "connection")
+		options = section("set", "options", "client")
+	)
+	left := []*compareSection{setup, connect, publish, drain}
+	right := []*compareSection{section(), section("connect", "the", "server"), options, section("publish", "message"), section("close")}
+
+	rows := alignSections(left, right)
+
+	expected := []*compareRow{
+		{Left: setup, Right: right[0]},
+		{Left: connect, Right: right[1]},
+		{Right: options},
+		{Left: publish, Right: right[3]},
+		{Left: drain},
+		{Right: right[4]},
+	}
+	checkEqual(t, len(rows), len(expected))
+	for i, r := range rows {
+		if i >= len(expected) {
+			break
+		}
+		if r.Left != expected[i].Left || r.Right != expected[i].Right {
+			t.Errorf("row %d: expected %v, got %v", i, expected[i], r)
+		}
+	}
+}
+
+func TestBuildCompare(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, pubSubTree(map[string]string{
+		// Both fall back to the shell language.
+		"examples/messaging/pub-sub/cobol/main.sh":   "# Say hello.\necho cobol\n",
+		"examples/messaging/pub-sub/fortran/main.sh": "# Say hello.\necho fortran\n",
+	}))
+
+	b := Builder{
+		Source: filepath.Join(dir, "examples"),
+		Static: filepath.Join(dir, "static"),
+		Output: filepath.Join(dir, "html"),
+	}
+	if err := b.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	example := filepath.Join(b.Output, b.Source, "messaging/pub-sub")
+	page, err := os.ReadFile(filepath.Join(example, compareDir, "cobol..fortran/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sections of each client are shown, not those of one of them twice.
+	checkEqual(t, strings.Contains(string(page), "</span> cobol</span>"), true)
+	checkEqual(t, strings.Contains(string(page), "</span> fortran</span>"), true)
+
+	for _, p := range []string{"go..cobol", "go..fortran"} {
+		if _, err := os.Stat(filepath.Join(example, compareDir, p, "index.html")); err != nil {
+			t.Error(err)
+		}
+	}
+}
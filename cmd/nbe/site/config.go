@@ -0,0 +1,196 @@
+package site
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultBaseURL is the URL the site is served from, used for the canonical
+// links, sitemap and feed.
+const DefaultBaseURL = "https://natsbyexample.com"
+
+// Config holds the settings of a site which are not derived from the
+// examples, e.g. for hosting a fork with its own examples.
+type Config struct {
+	// URL the site is served from.
+	BaseURL string `yaml:"base_url"`
+	// Title of the site, used as the prefix of the page titles.
+	Title string `yaml:"title"`
+	// Logo shown in the header, relative to the static directory or a URL.
+	Logo string `yaml:"logo"`
+	// Alternative text of the logo.
+	LogoAlt string `yaml:"logo_alt"`
+	// Text following the logo in the header, e.g. by Example.
+	LogoText string `yaml:"logo_text"`
+	// Google Analytics measurement ID, e.g. G-XXXXXXXXXX. If empty, the pages
+	// do not include the analytics script.
+	Analytics string `yaml:"analytics"`
+	// URL of the repository, linked for the getting started guide.
+	RepoURL string `yaml:"repo_url"`
+	// Pattern of the URL of a client directory in the repository, with
+	// {branch} and {path} replaced, e.g. for GitLab
+	// https://gitlab.com/org/repo/-/tree/{branch}/{path}.
+	SourceURL string `yaml:"source_url"`
+	// Pattern of the URL of a range of lines of a file in the repository,
+	// with {branch}, {path}, {start} and {end} replaced. If empty, the
+	// blocks of the client pages do not link to their source lines. If only
+	// SourceURL is set, it is derived from it for GitHub and GitLab.
+	SourceLinesURL string `yaml:"source_lines_url"`
+	// Branch of the repository the source links point to.
+	Branch string `yaml:"branch"`
+	// Metadata for link previews on social media.
+	Social SocialConfig `yaml:"social"`
+	// Syntax highlighting of the code blocks.
+	Highlight HighlightConfig `yaml:"highlight"`
+	// Names of the languages shown first in the language tabs, in order.
+	// The other languages follow in the order of the registry.
+	Languages []string `yaml:"languages"`
+}
+
+// SocialConfig is the metadata of the client pages used for link previews.
+type SocialConfig struct {
+	// Twitter handle of the author, e.g. @thedevel.
+	Twitter string `yaml:"twitter"`
+	// Image of the preview, relative to the static directory or a URL.
+	// Used for the pages without a generated card.
+	Image string `yaml:"image"`
+	// Alternative text of the image.
+	ImageAlt string `yaml:"image_alt"`
+	// TrueType font of the preview images generated for the example and
+	// client pages, relative to the static directory. If empty or missing,
+	// no images are generated and Image is used instead.
+	CardFont string `yaml:"card_font"`
+}
+
+// HighlightConfig selects the chroma styles of the code blocks.
+type HighlightConfig struct {
+	// Name of the chroma style, e.g. github. If not set, the highlighting
+	// of main.css is used.
+	Style string `yaml:"style"`
+	// Name of the chroma style used if the browser prefers a dark color
+	// scheme, e.g. github-dark.
+	DarkStyle string `yaml:"dark_style"`
+	// Show the line numbers of the source file in the code blocks.
+	LineNumbers bool `yaml:"line_numbers"`
+}
+
+// DefaultConfig returns the configuration of natsbyexample.com.
+func DefaultConfig() *Config {
+	return &Config{
+		BaseURL:        DefaultBaseURL,
+		Title:          "NATS by Example",
+		Logo:           "/nats-horizontal-color.svg",
+		LogoAlt:        "NATS Logo",
+		LogoText:       "by Example",
+		Analytics:      "G-6242VH03CH",
+		RepoURL:        "https://github.com/ConnectEverything/nats-by-example",
+		SourceURL:      "https://github.com/ConnectEverything/nats-by-example/tree/{branch}/{path}",
+		SourceLinesURL: "https://github.com/ConnectEverything/nats-by-example/blob/{branch}/{path}#L{start}-L{end}",
+		Branch:         "main",
+		Social: SocialConfig{
+			Twitter:  "@thedevel",
+			Image:    "/nbe-twitter.png",
+			ImageAlt: "NATS by Example",
+			CardFont: "/Cookie-Regular.ttf",
+		},
+	}
+}
+
+// LoadConfig reads the configuration file at path. Unset fields default to
+// those of DefaultConfig.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := DefaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if !strings.Contains(c.SourceURL, "{path}") {
+		return nil, fmt.Errorf("%s: source_url %q must contain {path}", path, c.SourceURL)
+	}
+
+	// The default source lines URL points to the upstream repository, so
+	// it does not apply to another source URL.
+	var set struct {
+		SourceURL      *string `yaml:"source_url"`
+		SourceLinesURL *string `yaml:"source_lines_url"`
+	}
+	if err := yaml.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if set.SourceURL != nil && set.SourceLinesURL == nil {
+		lines, ok := deriveSourceLinesURL(c.SourceURL)
+		if !ok {
+			return nil, fmt.Errorf("%s: source_lines_url must be set along with source_url %q, or to an empty string", path, c.SourceURL)
+		}
+		c.SourceLinesURL = lines
+	}
+
+	if c.SourceLinesURL != "" && !strings.Contains(c.SourceLinesURL, "{path}") {
+		return nil, fmt.Errorf("%s: source_lines_url %q must contain {path}", path, c.SourceLinesURL)
+	}
+	for _, s := range []string{c.Highlight.Style, c.Highlight.DarkStyle} {
+		if err := checkStyle(s); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+// deriveSourceLinesURL returns the pattern of the URL of the lines of a file
+// for the pattern of the URL of a directory on GitHub or GitLab.
+func deriveSourceLinesURL(source string) (string, bool) {
+	switch {
+	case strings.Contains(source, "/-/tree/"):
+		return strings.Replace(source, "/-/tree/", "/-/blob/", 1) + "#L{start}-{end}", true
+	case strings.HasPrefix(source, "https://github.com/") && strings.Contains(source, "/tree/"):
+		return strings.Replace(source, "/tree/", "/blob/", 1) + "#L{start}-L{end}", true
+	}
+	return "", false
+}
+
+// sourceURL returns the URL of the directory in the repository.
+func (c *Config) sourceURL(dir string) string {
+	r := strings.NewReplacer(
+		"{branch}", c.Branch,
+		"{path}", filepath.ToSlash(dir),
+	)
+	return r.Replace(c.SourceURL)
+}
+
+// sourceLinesURL returns the URL of the lines of the file in the repository,
+// or an empty string if there is no pattern.
+func (c *Config) sourceLinesURL(file string, start, end int) string {
+	if c.SourceLinesURL == "" {
+		return ""
+	}
+	r := strings.NewReplacer(
+		"{branch}", c.Branch,
+		"{path}", filepath.ToSlash(file),
+		"{start}", strconv.Itoa(start),
+		"{end}", strconv.Itoa(end),
+	)
+	return r.Replace(c.SourceLinesURL)
+}
+
+// pageTitle returns the title of a page prefixed by the site title.
+func (c *Config) pageTitle(format string, args ...any) string {
+	if format == "" {
+		return c.Title
+	}
+	return c.Title + " - " + fmt.Sprintf(format, args...)
+}
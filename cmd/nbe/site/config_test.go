@@ -0,0 +1,100 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "site.yaml")
+
+	write := func(s string) {
+		if err := os.WriteFile(path, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`
+title: Acme by Example
+source_url: https://gitlab.example.org/acme/examples/-/tree/{branch}/{path}
+branch: develop
+social:
+  twitter: "@acme"
+`)
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, c.BaseURL, DefaultBaseURL)
+	checkEqual(t, c.pageTitle("Tags"), "Acme by Example - Tags")
+	checkEqual(t, c.sourceURL("examples/kv/intro/go"), "https://gitlab.example.org/acme/examples/-/tree/develop/examples/kv/intro/go")
+	checkEqual(t, c.sourceLinesURL("examples/kv/intro/go/main.go", 3, 8), "https://gitlab.example.org/acme/examples/-/blob/develop/examples/kv/intro/go/main.go#L3-8")
+	checkEqual(t, c.Social.Twitter, "@acme")
+	checkEqual(t, c.Social.Image, "/nbe-twitter.png")
+
+	write("source_url: https://github.com/acme/examples/tree/{branch}/{path}\n")
+	c, err = LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, c.sourceLinesURL("examples/kv/intro/go/main.go", 3, 8), "https://github.com/acme/examples/blob/main/examples/kv/intro/go/main.go#L3-L8")
+
+	write("source_url: https://gitea.example.org/acme/examples/src/branch/{branch}/{path}\n")
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected an error for a source URL without a source lines URL")
+	}
+
+	write("source_url: https://gitea.example.org/acme/examples/src/branch/{branch}/{path}\nsource_lines_url: \"\"\n")
+	c, err = LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, c.SourceLinesURL, "")
+
+	write("title: Acme\nbase: https://example.org\n")
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+
+	write("source_url: https://example.org/acme\n")
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected an error for a source URL without {path}")
+	}
+}
+
+func TestBuildConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, pubSubTree(nil))
+
+	b := Builder{
+		Source: filepath.Join(dir, "examples"),
+		Static: filepath.Join(dir, "static"),
+		Output: filepath.Join(dir, "html"),
+	}
+	page := filepath.Join(b.Output, b.Source, "messaging/pub-sub/go/index.html")
+	read := func() string {
+		t.Helper()
+		if err := b.Run(); err != nil {
+			t.Fatal(err)
+		}
+		s, err := os.ReadFile(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(s)
+	}
+
+	s := read()
+	checkEqual(t, strings.Contains(s, "gtag/js?id=G-6242VH03CH"), true)
+	checkEqual(t, strings.Contains(s, `alt="NATS Logo" /> by Example`), true)
+
+	b.Config = DefaultConfig()
+	b.Config.Analytics = ""
+	b.Config.LogoText = "Examples"
+	s = read()
+	checkEqual(t, strings.Contains(s, "googletagmanager"), false)
+	checkEqual(t, strings.Contains(s, `alt="NATS Logo" /> Examples`), true)
+}
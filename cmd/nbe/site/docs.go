@@ -0,0 +1,1094 @@
+// Package site renders the examples as a static website.
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/russross/blackfriday/v2"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+
+	_ "embed"
+)
+
+var (
+	//go:embed tmpl/head.html
+	headInclude string
+
+	//go:embed tmpl/logo.html
+	logoInclude string
+
+	//go:embed tmpl/index.html
+	indexPage string
+
+	//go:embed tmpl/category.html
+	categoryPage string
+
+	//go:embed tmpl/example.html
+	examplePage string
+
+	//go:embed tmpl/client.html
+	clientPage string
+
+	//go:embed tmpl/meta.html
+	metaInclude string
+
+	//go:embed tmpl/block-links.html
+	blockLinksInclude string
+
+	//go:embed tmpl/tag.html
+	tagPage string
+
+	//go:embed tmpl/search.html
+	searchPage string
+
+	//go:embed tmpl/compare.html
+	comparePage string
+)
+
+type LanguageLink struct {
+	Name  string
+	Label string
+	Path  string
+}
+
+type Link struct {
+	Label string
+	Path  string
+}
+
+type indexCategory struct {
+	Title        string
+	Description  template.HTML
+	ExampleLinks []*Link
+}
+
+type indexData struct {
+	PageTitle    string
+	CanonicalURL template.URL
+	Categories   []*indexCategory
+	// True if any example has tags.
+	HasTags bool
+}
+
+type categoryData struct {
+	PageTitle    string
+	CanonicalURL template.URL
+	Title        string
+	Description  template.HTML
+	Examples     []*Link
+}
+
+type exampleData struct {
+	PageTitle     string
+	CanonicalURL  template.URL
+	CategoryTitle string
+	CategoryPath  string
+	Title         string
+	Description   template.HTML
+	Path          string
+	Links         []*LanguageLink
+	Meta          *exampleMeta
+	// First paragraph of the description as plain text.
+	Summary string
+	pageImage
+}
+
+type clientData struct {
+	PageTitle          string
+	CategoryTitle      string
+	CategoryPath       string
+	ExampleTitle       string
+	ExamplePath        string
+	ExampleDescription template.HTML
+	RunPath            string
+	Path               string
+	SourceURL          string
+	AsciinemaURL       template.URL
+	Language           string
+	Links              []*LanguageLink
+	// Compare pages of the client with each of the other clients.
+	Compare      []*Link
+	Files        []*RenderedFile
+	JSEscaped    string
+	CanonicalURL template.URL
+	Meta         *exampleMeta
+	// Recorded output, with the colors of the terminal, and as plain text
+	// for copying.
+	Output     template.HTML
+	OutputText string
+	// First paragraph of the example description as plain text.
+	ExampleSummary string
+	pageImage
+	// JSON-LD metadata of the source code.
+	JSONLD template.JS
+}
+
+// exampleMeta is the structured metadata of an example shown on the example
+// and client pages.
+type exampleMeta struct {
+	Level            string
+	MinServerVersion string
+	Features         []string
+	Tags             []*Link
+	Requires         []*Link
+	Related          []*Link
+}
+
+type tagData struct {
+	PageTitle    string
+	CanonicalURL template.URL
+	Title        string
+	Links        []*Link
+	// True for the page listing all tags.
+	Index bool
+}
+
+// generator renders the pages of the site.
+type generator struct {
+	langs  *lang.Registry
+	logger *log.Logger
+	config *Config
+	// Templates of the theme directory by name, overriding the embedded
+	// ones.
+	theme map[string]string
+	// Dates of the examples from the git history, keyed by example path.
+	history map[string]*exampleDates
+	// Font of the social cards, nil if they are not generated.
+	cardFont *cardFont
+}
+
+// generateDocs renders the site pages to the output. Pages whose inputs are
+// unchanged according to the output are skipped.
+func (g *generator) generateDocs(root *examples.Root, out siteOutput) error {
+	t := template.New("site").Funcs(template.FuncMap{
+		"site": func() *Config { return g.config },
+	})
+
+	// The templates of the theme are parsed first so they take precedence
+	// over the embedded ones of the same name.
+	for _, name := range sortedKeys(g.theme) {
+		if _, err := t.New(name).Parse(g.theme[name]); err != nil {
+			return fmt.Errorf("theme: %s: %w", name, err)
+		}
+	}
+	parse := func(name, src string) (*template.Template, error) {
+		if x := t.Lookup(name); x != nil {
+			return x, nil
+		}
+		x, err := t.New(name).Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return x, nil
+	}
+
+	if _, err := parse("head", headInclude); err != nil {
+		return err
+	}
+
+	if _, err := parse("logo", logoInclude); err != nil {
+		return err
+	}
+
+	if _, err := parse("meta", metaInclude); err != nil {
+		return err
+	}
+
+	if _, err := parse("block-links", blockLinksInclude); err != nil {
+		return err
+	}
+
+	rt, err := parse("index", indexPage)
+	if err != nil {
+		return err
+	}
+
+	ct, err := parse("category", categoryPage)
+	if err != nil {
+		return err
+	}
+
+	et, err := parse("example", examplePage)
+	if err != nil {
+		return err
+	}
+
+	it, err := parse("client", clientPage)
+	if err != nil {
+		return err
+	}
+
+	tt, err := parse("tag", tagPage)
+	if err != nil {
+		return err
+	}
+
+	st, err := parse("search", searchPage)
+	if err != nil {
+		return err
+	}
+
+	pt, err := parse("compare", comparePage)
+	if err != nil {
+		return err
+	}
+
+	if err := g.generateStyles(out); err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	cards := &cardQueue{}
+
+	var ics []*indexCategory
+	for _, c := range root.Categories {
+		ic := indexCategory{
+			Title:       c.Title,
+			Description: template.HTML(blackfriday.Run([]byte(c.Description))),
+		}
+		ics = append(ics, &ic)
+		for _, e := range c.Examples {
+			l := &Link{
+				Label: e.Title,
+			}
+			for _, k := range g.langs.Tabs() {
+				if c, ok := e.Clients[k.Name]; ok {
+					l.Path = c.Path
+					break
+				}
+			}
+			// Use the example title, but with the first client path.
+			ic.ExampleLinks = append(ic.ExampleLinks, l)
+		}
+	}
+
+	tags := g.tagLinks(root)
+
+	ix := indexData{
+		PageTitle:    g.config.pageTitle(""),
+		CanonicalURL: template.URL(g.pageURL("")),
+		Categories:   ics,
+		HasTags:      len(tags) > 0,
+	}
+
+	fresh, err := out.Fresh("index.html", &ix)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		err = rt.Execute(buf, &ix)
+		if err != nil {
+			return err
+		}
+
+		err = out.Write("index.html", buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range root.Categories {
+		buf.Reset()
+
+		var elinks []*Link
+		for _, e := range c.Examples {
+			elinks = append(elinks, &Link{
+				Label: e.Title,
+				Path:  e.Path,
+			})
+		}
+
+		cx := categoryData{
+			CanonicalURL: template.URL(g.pageURL(c.Path)),
+			Title:        c.Title,
+			Description:  template.HTML(blackfriday.Run([]byte(c.Description))),
+			Examples:     elinks,
+		}
+		page := filepath.Join(c.Path, "index.html")
+		fresh, err := out.Fresh(page, &cx)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			err = ct.Execute(buf, &cx)
+			if err != nil {
+				return err
+			}
+
+			err = out.Write(page, buf.Bytes())
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, e := range c.Examples {
+			buf.Reset()
+
+			clients := e.SortedClients(g.langs)
+			tabs := g.langs.Tabs()
+			links := make([]*LanguageLink, len(tabs))
+			for i, n := range tabs {
+				l := &LanguageLink{
+					Name:  n.Name,
+					Label: n.Label,
+				}
+				for _, i := range clients {
+					if i.Language == n.Name {
+						l.Path = i.Path
+						break
+					}
+				}
+				links[i] = l
+			}
+
+			ex := exampleData{
+				CanonicalURL:  template.URL(g.pageURL(e.Path)),
+				CategoryTitle: c.Title,
+				CategoryPath:  c.Path,
+				Description:   template.HTML(blackfriday.Run([]byte(e.Description))),
+				Summary:       summary(e.Description),
+				Title:         e.Title,
+				Path:          e.Path,
+				Links:         links,
+				Meta:          g.exampleMeta(root, e),
+			}
+			ex.pageImage, err = g.pageImage(e.Path, &socialCard{
+				Title:    e.Title,
+				Category: c.Title,
+				Site:     g.config.Title,
+			}, out, cards)
+			if err != nil {
+				return err
+			}
+			page := filepath.Join(e.Path, "index.html")
+			fresh, err := out.Fresh(page, &ex)
+			if err != nil {
+				return err
+			}
+			if !fresh {
+				err = et.Execute(buf, &ex)
+				if err != nil {
+					return err
+				}
+
+				err = out.Write(page, buf.Bytes())
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, i := range clients {
+				outputFile := filepath.Join(i.Path, "output.txt")
+
+				var castFile string
+				outputBytes, err := ioutil.ReadFile(outputFile)
+				if err != nil {
+					if os.IsNotExist(err) {
+						g.logger.Printf("%s: %s", outputFile, err)
+					}
+				} else {
+					castFile = filepath.Join(i.Path, "output.cast")
+				}
+
+				ix := clientData{
+					CategoryTitle:      c.Title,
+					CategoryPath:       c.Path,
+					ExampleTitle:       e.Title,
+					ExamplePath:        e.Path,
+					ExampleDescription: ex.Description,
+					ExampleSummary:     ex.Summary,
+					Path:               i.Path,
+					RunPath:            strings.TrimPrefix(i.Path, "examples/"),
+					SourceURL:          g.config.sourceURL(i.Path),
+					AsciinemaURL:       template.URL(castFile),
+					Links:              links,
+					Language:           g.langs.Label(i.Language),
+					JSEscaped:          i.Source,
+					Meta:               ex.Meta,
+					Compare:            g.compareLinks(e, clients, i),
+					CanonicalURL:       template.URL(g.pageURL(i.Path)),
+				}
+
+				ix.PageTitle = g.config.pageTitle("%s (%s)", ix.ExampleTitle, ix.Language)
+				ix.Output, ix.OutputText = renderANSI(string(outputBytes))
+
+				ix.JSONLD, err = g.clientJSONLD(e, i, ix.SourceURL)
+				if err != nil {
+					return err
+				}
+
+				ix.pageImage, err = g.pageImage(i.Path, &socialCard{
+					Title:    e.Title,
+					Category: c.Title,
+					Language: ix.Language,
+					Site:     g.config.Title,
+				}, out, cards)
+				if err != nil {
+					return err
+				}
+
+				// The rendered files are derived from the sources, so only
+				// the additional files need to be part of the key.
+				page := filepath.Join(i.Path, "index.html")
+				fresh, err := out.Fresh(page, &ix, i.Files)
+				if err != nil {
+					return err
+				}
+				if !fresh {
+					ix.Files, err = g.renderFiles(i)
+					if err != nil {
+						return err
+					}
+
+					buf.Reset()
+					err = it.Execute(buf, &ix)
+					if err != nil {
+						return err
+					}
+
+					err = out.Write(page, buf.Bytes())
+					if err != nil {
+						return err
+					}
+				}
+
+				castBytes, err := ioutil.ReadFile(castFile)
+				if err != nil {
+					if os.IsNotExist(err) {
+						g.logger.Printf("%s: %s", castFile, err)
+						continue
+					}
+					return err
+				}
+				fresh, err = out.Fresh(castFile, castBytes)
+				if err != nil {
+					return err
+				}
+				if !fresh {
+					if err := out.Write(castFile, castBytes); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := g.generateCompare(c, e, clients, out, pt); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := g.renderCards(cards, out); err != nil {
+		return err
+	}
+
+	if err := g.generateSearch(root, out, st); err != nil {
+		return err
+	}
+
+	if err := g.generateFeeds(root, tags, out); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	// The tag index followed by a page per tag.
+	index := tagData{
+		PageTitle:    g.config.pageTitle("Tags"),
+		CanonicalURL: template.URL(g.pageURL(tagsDir)),
+		Title:        "Tags",
+		Index:        true,
+	}
+	pages := map[string]*tagData{
+		tagsDir: &index,
+	}
+	for _, t := range tags {
+		index.Links = append(index.Links, &Link{
+			Label: fmt.Sprintf("%s (%d)", t.Label, len(t.Examples)),
+			Path:  t.Path,
+		})
+		pages[t.Path] = &tagData{
+			PageTitle:    g.config.pageTitle("%s", t.Label),
+			CanonicalURL: template.URL(g.pageURL(t.Path)),
+			Title:        fmt.Sprintf("Examples tagged %s", t.Label),
+			Links:        t.Examples,
+		}
+	}
+
+	for p, td := range pages {
+		page := filepath.Join(p, "index.html")
+		fresh, err := out.Fresh(page, td)
+		if err != nil {
+			return err
+		}
+		if fresh {
+			continue
+		}
+
+		buf.Reset()
+		if err := tt.Execute(buf, td); err != nil {
+			return err
+		}
+		if err := out.Write(page, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Directory of the tag pages in the output.
+const tagsDir = "tags"
+
+type tagLink struct {
+	Label    string
+	Path     string
+	Examples []*Link
+}
+
+// tagLinks returns the tags of all examples sorted by name, each with the
+// examples having the tag in the order they appear on the index.
+func (g *generator) tagLinks(root *examples.Root) []*tagLink {
+	byName := make(map[string]*tagLink)
+	for _, c := range root.Categories {
+		for _, e := range c.Examples {
+			for _, t := range e.Tags {
+				tl, ok := byName[t]
+				if !ok {
+					tl = &tagLink{
+						Label: t,
+						Path:  path.Join(tagsDir, t),
+					}
+					byName[t] = tl
+				}
+				tl.Examples = append(tl.Examples, &Link{
+					Label: fmt.Sprintf("%s / %s", c.Title, e.Title),
+					Path:  g.examplePath(e),
+				})
+			}
+		}
+	}
+
+	tags := make([]*tagLink, 0, len(byName))
+	for _, tl := range byName {
+		tags = append(tags, tl)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Label < tags[j].Label
+	})
+	return tags
+}
+
+// examplePath returns the path of the first client of the example in the
+// order of the language tabs, or the example page if there is none.
+func (g *generator) examplePath(e *examples.Example) string {
+	for _, k := range g.langs.Tabs() {
+		if c, ok := e.Clients[k.Name]; ok {
+			return c.Path
+		}
+	}
+	return e.Path
+}
+
+// exampleMeta returns the structured metadata of the example, or nil if it
+// has none.
+func (g *generator) exampleMeta(root *examples.Root, e *examples.Example) *exampleMeta {
+	m := exampleMeta{
+		Level:            e.Level,
+		MinServerVersion: e.MinServerVersion,
+		Features:         e.Features,
+	}
+	for _, t := range e.Tags {
+		m.Tags = append(m.Tags, &Link{
+			Label: t,
+			Path:  path.Join(tagsDir, t),
+		})
+	}
+
+	links := func(refs []string) []*Link {
+		var ls []*Link
+		for _, ref := range refs {
+			if x := root.Example(ref); x != nil {
+				ls = append(ls, &Link{
+					Label: x.Title,
+					Path:  g.examplePath(x),
+				})
+			}
+		}
+		return ls
+	}
+	m.Requires = links(e.Requires)
+	m.Related = links(e.Related)
+
+	if m.Level == "" && m.MinServerVersion == "" && m.Features == nil && m.Tags == nil && m.Requires == nil && m.Related == nil {
+		return nil
+	}
+	return &m
+}
+
+func commonPrefixForLines(lines []string, delim string) (string, int) {
+	// Find the first line with a prefix.
+	for i, l := range lines {
+		// Ignore leading empty lines.
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		// Get the leading whitespace for the first comment line. Assume all are
+		// indented at the same level.
+		idx := strings.Index(l, delim)
+		if idx < 0 {
+			idx = len(l) - len(strings.TrimLeft(l, " \t"))
+		}
+		return l[:idx], i
+	}
+
+	return "", -1
+}
+
+// Remove leading whitespace and comment delimiters. Remove shortest whitespace
+// prefix after delimiters. Leading and trailing empty lines are removed, interleaved
+// ones are preserved for rendering. If multiple delimiters are given, the longest
+// matching one is removed from each line, e.g. /// rather than //.
+func cleanSingleCommentLines(lines []string, delims ...string) (string, string) {
+	ds := make([]string, len(delims))
+	copy(ds, delims)
+	sort.Slice(ds, func(i, j int) bool {
+		return len(ds[i]) > len(ds[j])
+	})
+
+	var shortest string
+	if len(ds) > 0 {
+		shortest = ds[len(ds)-1]
+	}
+	prefix, first := commonPrefixForLines(lines, shortest)
+
+	var cleaned []string
+	for _, l := range lines[first:] {
+		// Trim leading whitespace.
+		l = strings.TrimPrefix(l, prefix)
+		// Trim comment characters.
+		for _, d := range ds {
+			if strings.HasPrefix(l, d) {
+				l = strings.TrimPrefix(l, d)
+				break
+			}
+		}
+		// Assume space after delim.
+		l = strings.TrimPrefix(l, " ")
+		cleaned = append(cleaned, l)
+	}
+
+	return strings.TrimSpace(strings.Join(cleaned, "\n")), prefix
+}
+
+// Dedent relative to the smallest indent for all lines. Also remove
+// the comment syntax. The delimiters of the comment are determined from
+// the first line, defaulting to C-style comments. Repeated delimiter
+// characters, such as /** or **/, are removed as well.
+func cleanMultiCommentLines(lines []string, delims ...[2]string) (string, string) {
+	if len(delims) == 0 {
+		delims = [][2]string{{"/*", "*/"}}
+	}
+
+	open, close := delims[0][0], delims[0][1]
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if t == "" {
+			continue
+		}
+		var best string
+		for _, d := range delims {
+			if strings.HasPrefix(t, d[0]) && len(d[0]) > len(best) {
+				best = d[0]
+				open, close = d[0], d[1]
+			}
+		}
+		break
+	}
+
+	prefix, first := commonPrefixForLines(lines, open)
+
+	var cleaned []string
+	var lastIdx int
+	for i, l := range lines[first:] {
+		l = strings.TrimPrefix(l, prefix)
+		if i == 0 {
+			l = strings.TrimPrefix(l, open)
+			if c := open[len(open)-1]; !isIdentByte(c) {
+				l = strings.TrimLeft(l, string(c))
+			}
+		}
+		cleaned = append(cleaned, l)
+		if strings.TrimSpace(l) != "" {
+			lastIdx = i
+		}
+	}
+
+	last := strings.TrimRight(cleaned[lastIdx], " \t")
+	last = strings.TrimSuffix(last, close)
+	if c := close[0]; !isIdentByte(c) {
+		last = strings.TrimRight(last, string(c))
+	}
+	cleaned[lastIdx] = last
+
+	return strings.TrimSpace(strings.Join(cleaned, "\n")), prefix
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (g *generator) chromaFormat(code, lang string, options ...html.Option) (string, error) {
+	if l, ok := g.langs.Get(lang); ok {
+		lang = l.LexerName()
+	}
+
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	if lang == "output" {
+		lexer = SimpleShellOutputLexer
+	}
+
+	lexer = chroma.Coalesce(lexer)
+
+	// The code is highlighted with classes, the style only affects the
+	// stylesheet written by generateStyles.
+	style := styles.Get("swapoff")
+	formatter := html.New(append([]html.Option{html.WithClasses(true)}, options...)...)
+	iterator, err := lexer.Tokenise(nil, string(code))
+	if err != nil {
+		return "", err
+	}
+	buf := bytes.NewBuffer(nil)
+	err = formatter.Format(buf, style, iterator)
+	return buf.String(), nil
+}
+
+// renderFiles renders the main file of the client followed by the additional
+// files, if any.
+func (g *generator) renderFiles(c *examples.Client) ([]*RenderedFile, error) {
+	groups, err := g.renderBlocks(c.Language, fileID(c.MainFile), filepath.Join(c.Path, c.MainFile), c.Blocks)
+	if err != nil {
+		return nil, err
+	}
+	files := []*RenderedFile{{
+		Name:   c.MainFile,
+		ID:     fileID(c.MainFile),
+		Groups: groups,
+	}}
+
+	for _, f := range c.Files {
+		lang := f.Language
+		// Highlight unsupported languages based on the file name.
+		if lang == "" {
+			lang = "text"
+			if l := lexers.Match(filepath.Base(f.Name)); l != nil {
+				lang = l.Config().Name
+			}
+		}
+		groups, err := g.renderBlocks(lang, fileID(f.Name), filepath.Join(c.Path, f.Name), f.Blocks)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		files = append(files, &RenderedFile{
+			Name:   f.Name,
+			ID:     fileID(f.Name),
+			Groups: groups,
+		})
+	}
+
+	return files, nil
+}
+
+var fileIDRe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// fileID returns the HTML id of a file section.
+func fileID(name string) string {
+	return "file-" + strings.Trim(fileIDRe.ReplaceAllString(name, "-"), "-")
+}
+
+// renderBlocks renders the visible blocks, grouped by the collapsed section
+// they belong to. Empty blocks are added where needed so comments and code
+// alternate within each group. The file is the path of the source file in the
+// repository.
+func (g *generator) renderBlocks(lang, fid, file string, blocks []*examples.Block) ([]*BlockGroup, error) {
+	var (
+		visible []*examples.Block
+		skipped []bool
+	)
+	hidden := false
+	for _, b := range blocks {
+		if b.Hidden {
+			hidden = true
+			continue
+		}
+		visible = append(visible, b)
+		skipped = append(skipped, hidden)
+		hidden = false
+	}
+
+	var (
+		groups []*BlockGroup
+		group  *BlockGroup
+	)
+	for j, b := range visible {
+		if group == nil || group.Collapse != b.Collapse {
+			group = &BlockGroup{Collapse: b.Collapse}
+			groups = append(groups, group)
+
+			// Always start with a comment block...
+			if b.Type == examples.CodeBlock {
+				group.Blocks = append(group.Blocks, &RenderedBlock{Type: "comment"})
+			}
+		}
+
+		if b.Type == examples.BreakBlock {
+			// Unless a break is added to the end of the file, there will
+			// always be a following block. If a break happens in the middle
+			// of two comments, we need to added an empty code block, otherwise
+			// we need to add an empty comment block.
+			if len(visible) > j+1 {
+				nb := visible[j+1]
+				if nb.Type == examples.CodeBlock {
+					group.Blocks = append(group.Blocks, &RenderedBlock{Type: "comment"})
+				} else {
+					group.Blocks = append(group.Blocks, &RenderedBlock{Type: "code"})
+				}
+			}
+			continue
+		}
+
+		rb, err := g.renderBlock(lang, fid, file, b)
+		if err != nil {
+			return nil, err
+		}
+
+		// Hidden blocks in between may leave two comments or two code
+		// blocks next to each other.
+		if n := len(group.Blocks); skipped[j] && n > 0 && group.Blocks[n-1].Type == rb.Type {
+			if rb.Type == "comment" {
+				group.Blocks = append(group.Blocks, &RenderedBlock{Type: "code"})
+			} else {
+				group.Blocks = append(group.Blocks, &RenderedBlock{Type: "comment"})
+			}
+		}
+
+		group.Blocks = append(group.Blocks, rb)
+	}
+
+	return groups, nil
+}
+
+func (g *generator) renderBlock(lang, fid, file string, block *examples.Block) (*RenderedBlock, error) {
+	var r RenderedBlock
+	switch block.Type {
+	case examples.CodeBlock:
+		r.Type = "code"
+		text := strings.Join(block.Lines, "\n")
+
+		// The lines of a code block are those of the file, so the numbers
+		// start from the first line of the block.
+		var options []html.Option
+		if g.config.Highlight.LineNumbers {
+			options = append(options, html.WithLineNumbers(true), html.BaseLineNumber(block.StartLine))
+		}
+		html, err := g.chromaFormat(text, lang, options...)
+		if err != nil {
+			return nil, err
+		}
+		r.HTML = template.HTML(html)
+
+	case examples.SingleLineCommentBlock, examples.MultiLineCommentBlock:
+		text, indent := g.commentText(lang, block)
+		r.Type = "comment"
+		r.HTML = template.HTML(blackfriday.Run([]byte(text)))
+		r.Prefix = indent
+
+	default:
+		return &r, nil
+	}
+
+	r.ID = blockID(fid, block)
+	r.StartLine, r.EndLine = lineRange(block)
+	r.SourceURL = g.config.sourceLinesURL(file, r.StartLine, r.EndLine)
+	return &r, nil
+}
+
+// lineRange returns the first and last line of the block which are not
+// blank, or those of the block if all are.
+func lineRange(block *examples.Block) (int, int) {
+	start, end := block.StartLine, block.EndLine
+	for i, l := range block.Lines {
+		if strings.TrimSpace(l) != "" {
+			start = block.StartLine + i
+			break
+		}
+	}
+	for i := len(block.Lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(block.Lines[i]) != "" {
+			end = block.StartLine + i
+			break
+		}
+	}
+	return start, end
+}
+
+// commentText returns the markdown of a comment block with the comment
+// delimiters removed, along with the indent of the lines.
+func (g *generator) commentText(name string, block *examples.Block) (string, string) {
+	l, ok := g.langs.Get(name)
+	ok = ok && l.Syntax != nil
+
+	if block.Type == examples.MultiLineCommentBlock {
+		var delims [][2]string
+		if ok {
+			delims = l.Syntax.CommentDelims()
+		}
+		return cleanMultiCommentLines(block.Lines, delims...)
+	}
+
+	var delims []string
+	if ok {
+		delims = l.Syntax.LineCommentDelims()
+	}
+	return cleanSingleCommentLines(block.Lines, delims...)
+}
+
+// blockID returns the HTML id of a block, based on the line it starts at so
+// it is stable across edits elsewhere in the file.
+func blockID(fid string, block *examples.Block) string {
+	return fmt.Sprintf("%s-L%d", fid, block.StartLine)
+}
+
+type RenderedBlock struct {
+	// Comment or Code
+	Type string
+
+	// HTML id of the block, empty for the placeholders added to alternate
+	// comments and code.
+	ID string
+
+	// Lines of the source file the block spans, excluding leading and
+	// trailing blank lines.
+	StartLine int
+	EndLine   int
+
+	// URL of the lines in the repository, if configured.
+	SourceURL string
+
+	// HTML rendered content. comment -> markdown, code -> syntax highlighted
+	HTML template.HTML
+
+	// Prefix string for the non-empty lines.
+	Prefix string
+}
+
+// RenderedFile is a source file of a client, shown as a file tab.
+type RenderedFile struct {
+	// Name of the file relative to the client directory.
+	Name string
+	// HTML id of the file section.
+	ID     string
+	Groups []*BlockGroup
+}
+
+// BlockGroup is a run of rendered blocks, optionally shown as a collapsed
+// section with the given title.
+type BlockGroup struct {
+	Collapse string
+	Blocks   []*RenderedBlock
+}
+
+var SimpleShellOutputLexer = chroma.MustNewLexer(
+	&chroma.Config{
+		Name:      "Shell Output",
+		Aliases:   []string{"console"},
+		Filenames: []string{"*.sh"},
+		MimeTypes: []string{},
+	},
+	func() chroma.Rules {
+		return chroma.Rules{
+			"root": {
+				// $ or > triggers the start of prompt formatting
+				chroma.Rule{
+					Pattern: `^\$`,
+					Type:    chroma.GenericPrompt,
+					Mutator: chroma.Push("prompt"),
+				},
+				chroma.Rule{
+					Pattern: `^>`,
+					Type:    chroma.GenericPrompt,
+					Mutator: chroma.Push("prompt"),
+				},
+
+				// empty lines are just text
+				chroma.Rule{
+					Pattern: `^$\n`,
+					Type:    chroma.Text,
+					Mutator: nil,
+				},
+
+				// otherwise its all output
+				chroma.Rule{
+					Pattern: `[^\n]+$\n?`,
+					Type:    chroma.GenericOutput,
+					Mutator: nil,
+				},
+			},
+			"prompt": {
+				// when we find newline, do output formatting rules
+				chroma.Rule{
+					Pattern: `\n`,
+					Type:    chroma.Text,
+					Mutator: chroma.Push("output"),
+				},
+				// otherwise its all text
+				chroma.Rule{
+					Pattern: `[^\n]+$`,
+					Type:    chroma.Text,
+					Mutator: nil,
+				},
+			},
+			"output": {
+				// sometimes there isn't output so we go right back to prompt
+				chroma.Rule{
+					Pattern: `^\$`,
+					Type:    chroma.GenericPrompt,
+					Mutator: chroma.Pop(1),
+				},
+				chroma.Rule{
+					Pattern: `^>`,
+					Type:    chroma.GenericPrompt,
+					Mutator: chroma.Pop(1),
+				},
+				// otherwise its all output
+				chroma.Rule{
+					Pattern: `[^\n]+$\n?`,
+					Type:    chroma.GenericOutput,
+					Mutator: nil,
+				},
+			},
+		}
+	},
+)
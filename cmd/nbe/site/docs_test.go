@@ -0,0 +1,132 @@
+package site
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+func TestCleanSingleCommentLines(t *testing.T) {
+	input := `
+				// Hello world
+				// This is a comment
+				// with some indents
+				// and more //
+				//
+`
+
+	expected := `Hello world
+This is a comment
+with some indents
+and more //`
+
+	lines := strings.Split(input, "\n")
+	output, prefix := cleanSingleCommentLines(lines, "//")
+
+	t.Logf("%v", []byte(prefix))
+
+	if diff := cmp.Diff(expected, output); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestCleanMultiCommentLines(t *testing.T) {
+	input := `
+				/*
+				Hello world
+				This is a comment
+				with some indents
+				and more //
+				*/
+`
+
+	expected := `Hello world
+This is a comment
+with some indents
+and more //`
+
+	lines := strings.Split(input, "\n")
+	output, prefix := cleanMultiCommentLines(lines)
+
+	t.Logf("%v", []byte(prefix))
+
+	if diff := cmp.Diff(expected, output); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestCleanDocCommentLines(t *testing.T) {
+	lines := strings.Split(`
+	/// Hello world
+	//! and more`, "\n")
+	rust, _ := lang.Default.Get(lang.Rust)
+	output, _ := cleanSingleCommentLines(lines, rust.Syntax.LineCommentDelims()...)
+	if diff := cmp.Diff("Hello world\nand more", output); diff != "" {
+		t.Error(diff)
+	}
+
+	delims := [][2]string{{`"""`, `"""`}, {"=begin", "=end"}, {`@doc """`, `"""`}, {"/*", "*/"}}
+
+	for _, input := range []string{
+		"    \"\"\"\n    Hello world\n    \"\"\"",
+		"=begin\nHello world\n=end",
+		"  @doc \"\"\"\n  Hello world\n  \"\"\"",
+		"/**\nHello world\n**/",
+	} {
+		output, _ := cleanMultiCommentLines(strings.Split(input, "\n"), delims...)
+		if diff := cmp.Diff("Hello world", output); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestRenderBlockLines(t *testing.T) {
+	src := `package main
+
+// Connect to the server.
+
+func main() {
+	nc, _ := nats.Connect(nats.DefaultURL)
+
+	defer nc.Drain()
+}
+`
+	p := examples.Parser{Languages: lang.Default}
+	blocks, _, err := p.ParseSource(lang.Go, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := DefaultConfig()
+	c.Highlight.LineNumbers = true
+	g := generator{langs: lang.Default, config: c}
+	groups, err := g.renderBlocks(lang.Go, "file-main-go", "examples/messaging/pub-sub/go/main.go", blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rbs := groups[0].Blocks
+	checkEqual(t, len(rbs), 4)
+
+	// The blank lines following the blocks are not part of their range.
+	checkEqual(t, rbs[1].ID, "file-main-go-L1")
+	checkEqual(t, rbs[1].EndLine, 1)
+	checkEqual(t, rbs[2].ID, "file-main-go-L3")
+	checkEqual(t, rbs[2].StartLine, 3)
+	checkEqual(t, rbs[2].EndLine, 3)
+	checkEqual(t, rbs[3].ID, "file-main-go-L5")
+	checkEqual(t, rbs[3].StartLine, 5)
+	checkEqual(t, rbs[3].EndLine, 9)
+	checkEqual(t, rbs[3].SourceURL, "https://github.com/ConnectEverything/nats-by-example/blob/main/examples/messaging/pub-sub/go/main.go#L5-L9")
+
+	// The line numbers are those of the file, including the blank line.
+	for _, n := range []string{"5", "7", "9"} {
+		if !strings.Contains(string(rbs[3].HTML), `<span class="ln">`+n+`</span>`) {
+			t.Errorf("expected line number %s in %s", n, rbs[3].HTML)
+		}
+	}
+}
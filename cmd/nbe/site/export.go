@@ -0,0 +1,378 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/internal/fsutil"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+// Formats of the exported files.
+const (
+	// Plain Markdown, with a section per client.
+	FormatMarkdown = "md"
+	// MDX, with a tab per client using the Tabs and TabItem components of
+	// Docusaurus.
+	FormatMDX = "mdx"
+)
+
+// Exporter writes the examples as Markdown or MDX files for other
+// documentation systems. The output mirrors the examples tree, with an index
+// file per category and a file per example, e.g.
+// messaging/pub-sub.md.
+type Exporter struct {
+	// Directory containing the examples.
+	Source string
+	// Directory the files are written to. Existing files of the same name
+	// are overwritten, others are left untouched.
+	Output string
+	// Format of the files, FormatMarkdown by default.
+	Format string
+	// Languages used to parse the examples. Defaults to lang.Default.
+	Languages *lang.Registry
+	// Logger for the clients which are skipped. Defaults to the standard
+	// logger.
+	Logger *log.Logger
+	// Settings of the site, root-relative links are made absolute with the
+	// base URL. Defaults to DefaultConfig.
+	Config *Config
+}
+
+// exportMeta is the front matter of an exported file.
+type exportMeta struct {
+	Title            string   `yaml:"title"`
+	Description      string   `yaml:"description,omitempty"`
+	SidebarPosition  int      `yaml:"sidebar_position,omitempty"`
+	Tags             []string `yaml:"tags,omitempty"`
+	Level            string   `yaml:"level,omitempty"`
+	MinServerVersion string   `yaml:"min_server_version,omitempty"`
+	Features         []string `yaml:"features,omitempty"`
+	Requires         []string `yaml:"requires,omitempty"`
+	Related          []string `yaml:"related,omitempty"`
+	Added            string   `yaml:"added,omitempty"`
+}
+
+// Run parses the examples and writes the exported files to the output
+// directory.
+func (x *Exporter) Run() error {
+	format := x.Format
+	if format == "" {
+		format = FormatMarkdown
+	}
+	if format != FormatMarkdown && format != FormatMDX {
+		return fmt.Errorf("unknown export format %q, expected %s or %s", format, FormatMarkdown, FormatMDX)
+	}
+
+	g, err := newGenerator(x.Languages, x.Logger, x.Config)
+	if err != nil {
+		return err
+	}
+
+	root, err := g.parse(x.Source)
+	if err != nil {
+		return err
+	}
+
+	for i, c := range root.Categories {
+		b, err := g.exportCategory(c, i+1, format)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.Path, err)
+		}
+		if err := fsutil.CreateFile(filepath.Join(x.Output, c.Name, "index."+format), b); err != nil {
+			return err
+		}
+
+		for j, e := range c.Examples {
+			b, err := g.exportExample(e, j+1, format)
+			if err != nil {
+				return fmt.Errorf("%s: %w", e.Path, err)
+			}
+			if err := fsutil.CreateFile(filepath.Join(x.Output, c.Name, e.Name+"."+format), b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportCategory returns the index file of a category, linking to the files
+// of its examples.
+func (g *generator) exportCategory(c *examples.Category, position int, format string) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := writeFrontMatter(buf, &exportMeta{
+		Title:           c.Title,
+		Description:     summary(c.Description),
+		SidebarPosition: position,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if d := strings.TrimSpace(c.Description); d != "" {
+		buf.WriteString(g.exportMarkdown(d, format))
+		buf.WriteString("\n\n")
+	}
+	for _, e := range c.Examples {
+		fmt.Fprintf(buf, "- [%s](./%s.%s)\n", e.Title, e.Name, format)
+	}
+	return trimFile(buf.Bytes()), nil
+}
+
+// exportExample returns the file of an example, with a section or tab per
+// client in the order of the language tabs.
+func (g *generator) exportExample(e *examples.Example, position int, format string) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	err := writeFrontMatter(buf, &exportMeta{
+		Title:            e.Title,
+		Description:      summary(e.Description),
+		SidebarPosition:  position,
+		Tags:             e.Tags,
+		Level:            e.Level,
+		MinServerVersion: e.MinServerVersion,
+		Features:         e.Features,
+		Requires:         e.Requires,
+		Related:          e.Related,
+		Added:            e.Added,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clients := e.SortedClients(g.langs)
+	if format == FormatMDX && len(clients) > 0 {
+		buf.WriteString("import Tabs from '@theme/Tabs';\nimport TabItem from '@theme/TabItem';\n\n")
+	}
+	if d := strings.TrimSpace(e.Description); d != "" {
+		buf.WriteString(g.exportMarkdown(d, format))
+		buf.WriteString("\n\n")
+	}
+	if len(clients) == 0 {
+		return trimFile(buf.Bytes()), nil
+	}
+
+	if format == FormatMDX {
+		buf.WriteString("<Tabs groupId=\"language\">\n")
+	}
+	for _, c := range clients {
+		label := g.langs.Label(c.Language)
+		if format == FormatMDX {
+			fmt.Fprintf(buf, "<TabItem value=\"%s\" label=\"%s\">\n\n", c.Name, label)
+		} else {
+			fmt.Fprintf(buf, "## %s\n\n", label)
+		}
+		if err := g.exportClient(buf, c, format); err != nil {
+			return nil, err
+		}
+		if format == FormatMDX {
+			buf.WriteString("</TabItem>\n")
+		}
+	}
+	if format == FormatMDX {
+		buf.WriteString("</Tabs>\n")
+	}
+	return trimFile(buf.Bytes()), nil
+}
+
+// exportClient writes the main file of the client followed by the additional
+// files and the output, if any.
+func (g *generator) exportClient(buf *bytes.Buffer, c *examples.Client, format string) error {
+	fmt.Fprintf(buf, "[View the source](%s) of this example.\n\n", g.config.sourceURL(c.Path))
+
+	if len(c.Files) > 0 {
+		fmt.Fprintf(buf, "### %s\n\n", c.MainFile)
+	}
+	g.exportBlocks(buf, c.Language, c.Blocks, format)
+
+	for _, f := range c.Files {
+		fmt.Fprintf(buf, "### %s\n\n", f.Name)
+		lang := f.Language
+		if lang == "" {
+			lang = strings.TrimPrefix(filepath.Ext(f.Name), ".")
+		}
+		g.exportBlocks(buf, lang, f.Blocks, format)
+	}
+
+	output, err := os.ReadFile(filepath.Join(c.Path, "output.txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	_, text := renderANSI(string(output))
+	buf.WriteString("### Output\n\n")
+	writeFence(buf, "text", strings.TrimRight(text, "\n"))
+	return nil
+}
+
+// exportBlocks writes the visible blocks of a file, comments as markdown and
+// code as fenced code blocks. Collapsed sections are written as details
+// elements.
+func (g *generator) exportBlocks(buf *bytes.Buffer, name string, blocks []*examples.Block, format string) {
+	fence := name
+	if l, ok := g.langs.Get(name); ok {
+		fence = l.LexerName()
+	}
+
+	var collapse string
+	for _, b := range blocks {
+		if b.Hidden || b.Type == examples.EmptyBlock || b.Type == examples.BreakBlock {
+			continue
+		}
+		if b.Collapse != collapse {
+			if collapse != "" {
+				buf.WriteString("</details>\n\n")
+			}
+			if b.Collapse != "" {
+				title := b.Collapse
+				if format == FormatMDX {
+					title = escapeMDX(title)
+				}
+				fmt.Fprintf(buf, "<details>\n<summary>%s</summary>\n\n", title)
+			}
+			collapse = b.Collapse
+		}
+
+		switch b.Type {
+		case examples.CodeBlock:
+			start, end := lineRange(b)
+			lines := b.Lines[start-b.StartLine : end-b.StartLine+1]
+			if strings.TrimSpace(strings.Join(lines, "")) == "" {
+				continue
+			}
+			writeFence(buf, fence, strings.Join(lines, "\n"))
+
+		case examples.SingleLineCommentBlock, examples.MultiLineCommentBlock:
+			text, _ := g.commentText(name, b)
+			if text == "" {
+				continue
+			}
+			buf.WriteString(g.exportMarkdown(text, format))
+			buf.WriteString("\n\n")
+		}
+	}
+	if collapse != "" {
+		buf.WriteString("</details>\n\n")
+	}
+}
+
+var (
+	mdRootLinkRe = regexp.MustCompile(`(\]\()(/[^)\s]*)`)
+	mdRootRefRe  = regexp.MustCompile(`(?m)^(\s*\[[^\]]+\]:\s*)(/\S*)`)
+)
+
+// exportMarkdown returns the markdown with the root-relative links made
+// absolute, since they point to pages of the site, and escaped for MDX.
+func (g *generator) exportMarkdown(md, format string) string {
+	abs := func(re *regexp.Regexp) func(string) string {
+		return func(s string) string {
+			m := re.FindStringSubmatch(s)
+			return m[1] + strings.TrimSuffix(g.config.BaseURL, "/") + m[2]
+		}
+	}
+	md = mdRootLinkRe.ReplaceAllStringFunc(md, abs(mdRootLinkRe))
+	md = mdRootRefRe.ReplaceAllStringFunc(md, abs(mdRootRefRe))
+	if format == FormatMDX {
+		md = escapeMDX(md)
+	}
+	return md
+}
+
+var autolinkRe = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+
+// escapeMDX escapes the characters MDX parses as JSX or expressions, outside
+// of code spans and fenced code blocks. Autolinks, which MDX does not
+// support, are written as links.
+func escapeMDX(md string) string {
+	var (
+		out   strings.Builder
+		fence string
+	)
+	for i, line := range strings.Split(md, "\n") {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+
+		t := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(t, fence) {
+				fence = ""
+			}
+			out.WriteString(line)
+			continue
+		case strings.HasPrefix(t, "```"), strings.HasPrefix(t, "~~~"):
+			fence = t[:3]
+			out.WriteString(line)
+			continue
+		}
+
+		line = autolinkRe.ReplaceAllString(line, "[$1]($1)")
+		code := false
+		for _, r := range line {
+			switch {
+			case r == '`':
+				code = !code
+			case code:
+			case r == '{' || r == '}':
+				out.WriteByte('\\')
+			case r == '<':
+				out.WriteString("&lt;")
+				continue
+			}
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+// writeFence writes the code as a fenced code block, with a fence longer than
+// any run of backticks in the code.
+func writeFence(buf *bytes.Buffer, info, code string) {
+	n, run := 3, 0
+	for _, r := range code {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		if run++; run >= n {
+			n = run + 1
+		}
+	}
+	fence := strings.Repeat("`", n)
+	fmt.Fprintf(buf, "%s%s\n%s\n%s\n\n", fence, info, code, fence)
+}
+
+// trimFile returns the contents of a file ending with a single newline.
+func trimFile(b []byte) []byte {
+	return append(bytes.TrimRight(b, "\n"), '\n')
+}
+
+func writeFrontMatter(buf *bytes.Buffer, meta *exportMeta) error {
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(meta); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	buf.WriteString("---\n\n")
+	return nil
+}
+
+// summary returns the first paragraph of the markdown as plain text.
+func summary(md string) string {
+	p, _, _ := strings.Cut(strings.TrimSpace(md), "\n\n")
+	return plainText(p)
+}
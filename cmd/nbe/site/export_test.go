@@ -0,0 +1,98 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExporter(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, pubSubTree(map[string]string{
+		"examples/messaging/meta.yaml":             "title: Messaging\ndescription: Core NATS.\n",
+		"examples/messaging/pub-sub/meta.yaml":     "title: Pub-Sub\ndescription: See the [intro](/examples/messaging/intro/go).\ntags: [core]\nlevel: beginner\n",
+		"examples/messaging/pub-sub/go/main.go":    "package main\n\n// Connect to the server, see <https://nats.io>.\nfunc main() {\n\t// <!collapse title=\"Options\">\n\topts := []nats.Option{}\n\t// <!show>\n}\n",
+		"examples/messaging/pub-sub/go/output.txt": "hello\n",
+	}))
+
+	source := filepath.Join(dir, "examples")
+	config := DefaultConfig()
+	config.SourceURL = "https://example.org/{path}"
+	x := Exporter{
+		Source: source,
+		Output: filepath.Join(dir, "docs"),
+		Format: FormatMDX,
+		Config: config,
+	}
+	if err := x.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(x.Output, "messaging/index.mdx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `---
+title: Messaging
+description: Core NATS.
+sidebar_position: 1
+---
+
+Core NATS.
+
+- [Pub-Sub](./pub-sub.mdx)
+`
+	if diff := cmp.Diff(expected, string(b)); diff != "" {
+		t.Error(diff)
+	}
+
+	b, err = os.ReadFile(filepath.Join(x.Output, "messaging/pub-sub.mdx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "---\n" +
+		"title: Pub-Sub\n" +
+		"description: See the intro.\n" +
+		"sidebar_position: 1\n" +
+		"tags:\n" +
+		"  - core\n" +
+		"level: beginner\n" +
+		"---\n\n" +
+		"import Tabs from '@theme/Tabs';\n" +
+		"import TabItem from '@theme/TabItem';\n\n" +
+		"See the [intro](https://natsbyexample.com/examples/messaging/intro/go).\n\n" +
+		"<Tabs groupId=\"language\">\n" +
+		"<TabItem value=\"go\" label=\"Go\">\n\n" +
+		"[View the source](https://example.org/" + filepath.ToSlash(source) + "/messaging/pub-sub/go) of this example.\n\n" +
+		"```go\npackage main\n```\n\n" +
+		"Connect to the server, see [https://nats.io](https://nats.io).\n\n" +
+		"```go\nfunc main() {\n```\n\n" +
+		"<details>\n<summary>Options</summary>\n\n" +
+		"```go\n\topts := []nats.Option{}\n```\n\n" +
+		"</details>\n\n" +
+		"```go\n}\n```\n\n" +
+		"### Output\n\n" +
+		"```text\nhello\n```\n\n" +
+		"</TabItem>\n" +
+		"</Tabs>\n"
+	if diff := cmp.Diff(expected, string(b)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestEscapeMDX(t *testing.T) {
+	tests := map[string]string{
+		"A map[string]any{} literal.":           `A map[string]any\{\} literal.`,
+		"A `map[string]any{}` literal.":         "A `map[string]any{}` literal.",
+		"Implements Handler<Msg>.":              "Implements Handler&lt;Msg>.",
+		"See <https://nats.io>.":                "See [https://nats.io](https://nats.io).",
+		"```go\nif x < 1 {\n}\n```\nAfter {x}.": "```go\nif x < 1 {\n}\n```\nAfter \\{x\\}.",
+	}
+	for input, expected := range tests {
+		if diff := cmp.Diff(expected, escapeMDX(input)); diff != "" {
+			t.Errorf("%q: %s", input, diff)
+		}
+	}
+}
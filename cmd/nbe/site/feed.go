@@ -0,0 +1,239 @@
+package site
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+)
+
+const (
+	sitemapFile = "sitemap.xml"
+	feedFile    = "atom.xml"
+
+	// Maximum number of examples in the feed.
+	feedSize = 50
+)
+
+// pageURL returns the absolute URL of a page directory, or a file if it has
+// an extension.
+func (g *generator) pageURL(p string) string {
+	u := strings.TrimSuffix(g.config.BaseURL, "/") + "/" + strings.TrimPrefix(filepath.ToSlash(p), "/")
+	if p != "" && filepath.Ext(p) == "" && !strings.HasSuffix(u, "/") {
+		u += "/"
+	}
+	return u
+}
+
+// assetURL returns the absolute URL of a static file, unless it is a URL
+// already.
+func (g *generator) assetURL(p string) string {
+	if strings.Contains(p, "://") {
+		return p
+	}
+	return g.pageURL(p)
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name      `xml:"urlset"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	URLs    []*sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"feed"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	ID      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Updated string       `xml:"updated"`
+	Links   []*atomLink  `xml:"link"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID         string          `xml:"id"`
+	Title      string          `xml:"title"`
+	Published  string          `xml:"published"`
+	Updated    string          `xml:"updated"`
+	Link       *atomLink       `xml:"link"`
+	Summary    string          `xml:"summary,omitempty"`
+	Categories []*atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// generateFeeds writes the sitemap of the index, category, example, client
+// and tag pages, along with an Atom feed of the most recently published
+// examples.
+func (g *generator) generateFeeds(root *examples.Root, tags []*tagLink, out siteOutput) error {
+	if skipFiles(out, sitemapFile, feedFile) {
+		return nil
+	}
+
+	sitemap := sitemapURLSet{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+	}
+	add := func(p string, t time.Time) {
+		u := sitemapURL{Loc: g.pageURL(p)}
+		if !t.IsZero() {
+			u.LastMod = t.Format(examples.DateLayout)
+		}
+		sitemap.URLs = append(sitemap.URLs, &u)
+	}
+
+	type dated struct {
+		category *examples.Category
+		example  *examples.Example
+		dates    exampleDates
+	}
+	var (
+		all     []*dated
+		updated time.Time
+	)
+
+	add("", time.Time{})
+	for _, c := range root.Categories {
+		var cupdated time.Time
+		for _, e := range c.Examples {
+			d := g.exampleDates(e)
+			if d.Updated.After(cupdated) {
+				cupdated = d.Updated
+			}
+			all = append(all, &dated{c, e, d})
+		}
+		if cupdated.After(updated) {
+			updated = cupdated
+		}
+		add(c.Path, cupdated)
+
+		for _, e := range c.Examples {
+			d := g.exampleDates(e)
+			add(e.Path, d.Updated)
+			for _, i := range e.SortedClients(g.langs) {
+				add(i.Path, d.Updated)
+			}
+		}
+	}
+	sitemap.URLs[0].LastMod = formatDate(updated)
+	if len(tags) > 0 {
+		add(tagsDir, time.Time{})
+		for _, t := range tags {
+			add(t.Path, time.Time{})
+		}
+	}
+
+	if err := g.writeXML(sitemapFile, &sitemap, out); err != nil {
+		return err
+	}
+
+	// Newest first, examples without a date are left out.
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].dates.Published.After(all[j].dates.Published)
+	})
+	feed := atomFeed{
+		Xmlns: "http://www.w3.org/2005/Atom",
+		ID:    g.pageURL(""),
+		Title: g.config.Title,
+		Links: []*atomLink{
+			{Href: g.pageURL("")},
+			{Rel: "self", Href: g.pageURL(feedFile)},
+		},
+	}
+	for _, x := range all {
+		if x.dates.Published.IsZero() || len(feed.Entries) == feedSize {
+			break
+		}
+		entry := atomEntry{
+			ID:        g.pageURL(x.example.Path),
+			Title:     fmt.Sprintf("%s: %s", x.category.Title, x.example.Title),
+			Published: x.dates.Published.Format(time.RFC3339),
+			Updated:   x.dates.Updated.Format(time.RFC3339),
+			Link:      &atomLink{Href: g.pageURL(g.examplePath(x.example))},
+			Summary:   plainText(x.example.Description),
+		}
+		for _, t := range x.example.Tags {
+			entry.Categories = append(entry.Categories, &atomCategory{Term: t})
+		}
+		feed.Entries = append(feed.Entries, &entry)
+	}
+	// The feed is written even without entries, since every page links to
+	// it.
+	feed.Updated = updated.Format(time.RFC3339)
+
+	return g.writeXML(feedFile, &feed, out)
+}
+
+func (g *generator) writeXML(name string, v any, out siteOutput) error {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	b = append([]byte(xml.Header), b...)
+
+	fresh, err := out.Fresh(name, b)
+	if err != nil || fresh {
+		return err
+	}
+	return out.Write(name, b)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(examples.DateLayout)
+}
+
+// sourceCode is the JSON-LD metadata of a client page.
+type sourceCode struct {
+	Context             string   `json:"@context"`
+	Type                string   `json:"@type"`
+	Name                string   `json:"name"`
+	Description         string   `json:"description,omitempty"`
+	URL                 string   `json:"url"`
+	CodeRepository      string   `json:"codeRepository"`
+	ProgrammingLanguage string   `json:"programmingLanguage"`
+	Keywords            []string `json:"keywords,omitempty"`
+	DateCreated         string   `json:"dateCreated,omitempty"`
+	DateModified        string   `json:"dateModified,omitempty"`
+}
+
+// clientJSONLD returns the SoftwareSourceCode metadata of a client page.
+func (g *generator) clientJSONLD(e *examples.Example, c *examples.Client, sourceURL string) (template.JS, error) {
+	d := g.exampleDates(e)
+	sc := sourceCode{
+		Context:             "https://schema.org",
+		Type:                "SoftwareSourceCode",
+		Name:                fmt.Sprintf("%s (%s)", e.Title, g.langs.Label(c.Language)),
+		Description:         plainText(e.Description),
+		URL:                 g.pageURL(c.Path),
+		CodeRepository:      sourceURL,
+		ProgrammingLanguage: g.langs.Label(c.Language),
+		Keywords:            e.Tags,
+		DateCreated:         formatDate(d.Published),
+		DateModified:        formatDate(d.Updated),
+	}
+	b, err := json.Marshal(&sc)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(b), nil
+}
@@ -0,0 +1,152 @@
+package site
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageURL(t *testing.T) {
+	g := generator{config: &Config{BaseURL: "https://example.org/nbe/"}}
+
+	checkEqual(t, g.pageURL(""), "https://example.org/nbe/")
+	checkEqual(t, g.pageURL("examples/kv/intro/go"), "https://example.org/nbe/examples/kv/intro/go/")
+	checkEqual(t, g.pageURL("atom.xml"), "https://example.org/nbe/atom.xml")
+	checkEqual(t, g.assetURL("/nbe-twitter.png"), "https://example.org/nbe/nbe-twitter.png")
+	checkEqual(t, g.assetURL("https://cdn.example.org/card.png"), "https://cdn.example.org/card.png")
+}
+
+func TestBuildFeeds(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, pubSubTree(nil))
+
+	b := Builder{
+		Source: filepath.Join(dir, "examples"),
+		Static: filepath.Join(dir, "static"),
+		Output: filepath.Join(dir, "html"),
+		Logger: log.New(io.Discard, "", 0),
+	}
+	// The paths of the pages include the absolute source directory.
+	base := DefaultBaseURL + filepath.ToSlash(b.Source)
+
+	build := func() (*sitemapURLSet, *atomFeed) {
+		t.Helper()
+		if err := b.Run(); err != nil {
+			t.Fatal(err)
+		}
+		var sitemap sitemapURLSet
+		var feed atomFeed
+		for name, v := range map[string]any{sitemapFile: &sitemap, feedFile: &feed} {
+			s, err := os.ReadFile(filepath.Join(b.Output, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := xml.Unmarshal(s, v); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+		}
+		return &sitemap, &feed
+	}
+	lastMods := func(sitemap *sitemapURLSet) map[string]string {
+		m := make(map[string]string)
+		for _, u := range sitemap.URLs {
+			m[strings.TrimPrefix(u.Loc, base)] = u.LastMod
+		}
+		return m
+	}
+
+	// Without dates, the feed is empty but still written for the links of
+	// the pages.
+	sitemap, feed := build()
+	checkEqual(t, len(sitemap.URLs), 4)
+	checkEqual(t, sitemap.URLs[0].Loc, DefaultBaseURL+"/")
+	for loc, mod := range lastMods(sitemap) {
+		if mod != "" {
+			t.Errorf("%s: unexpected lastmod %s", loc, mod)
+		}
+	}
+	checkEqual(t, len(feed.Entries), 0)
+	checkEqual(t, feed.Updated, "0001-01-01T00:00:00Z")
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("no git")
+	}
+	commit := func(date string) {
+		t.Helper()
+		for _, args := range [][]string{
+			{"add", "examples"},
+			{"-c", "user.name=test", "-c", "user.email=test@example.org", "commit", "-q", "-m", date},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %s: %s\n%s", args[0], err, out)
+			}
+		}
+	}
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s\n%s", err, out)
+	}
+	commit("2023-01-10T12:00:00Z")
+
+	// The added date overrides the first commit, so the later example is
+	// published first.
+	writeTree(t, dir, map[string]string{
+		"examples/messaging/request-reply/meta.yaml":  "title: Request-Reply\ndescription: Send a *request*.\nadded: 2022-06-01\ntags: [rpc]\n",
+		"examples/messaging/request-reply/go/main.go": "package main\n\nfunc main() {}\n",
+	})
+	commit("2023-03-01T12:00:00Z")
+
+	sitemap, feed = build()
+	mods := lastMods(sitemap)
+	// Along with the index, the tag pages are added.
+	checkEqual(t, len(mods), 8)
+	checkEqual(t, mods[DefaultBaseURL+"/tags/rpc/"], "")
+	checkEqual(t, sitemap.URLs[0].LastMod, "2023-03-01")
+	checkEqual(t, mods["/messaging/"], "2023-03-01")
+	checkEqual(t, mods["/messaging/pub-sub/"], "2023-01-10")
+	checkEqual(t, mods["/messaging/pub-sub/go/"], "2023-01-10")
+	checkEqual(t, mods["/messaging/request-reply/"], "2023-03-01")
+
+	checkEqual(t, feed.Updated, "2023-03-01T12:00:00Z")
+	if len(feed.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(feed.Entries))
+	}
+	checkEqual(t, feed.Entries[0].ID, base+"/messaging/pub-sub/")
+	checkEqual(t, feed.Entries[0].Published, "2023-01-10T12:00:00Z")
+	rr := feed.Entries[1]
+	checkEqual(t, rr.ID, base+"/messaging/request-reply/")
+	checkEqual(t, rr.Title, "Messaging: Request-Reply")
+	checkEqual(t, rr.Published, "2022-06-01T00:00:00Z")
+	checkEqual(t, rr.Updated, "2023-03-01T12:00:00Z")
+	checkEqual(t, rr.Summary, "Send a request.")
+	checkEqual(t, len(rr.Categories), 1)
+	checkEqual(t, rr.Categories[0].Term, "rpc")
+
+	// The client pages link their canonical URL and carry the dates in
+	// their metadata.
+	page, err := os.ReadFile(filepath.Join(b.Output, b.Source, "messaging/request-reply/go/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`<link rel="canonical" href="` + base + `/messaging/request-reply/go/" />`,
+		`<link rel="alternate" type="application/atom+xml"`,
+		`"@type":"SoftwareSourceCode"`,
+		`"dateCreated":"2022-06-01"`,
+		`"dateModified":"2023-03-01"`,
+	} {
+		if !bytes.Contains(page, []byte(s)) {
+			t.Errorf("client page does not contain %s", s)
+		}
+	}
+}
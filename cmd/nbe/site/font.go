@@ -0,0 +1,93 @@
+package site
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// cardFont is the font of the text of the social cards.
+type cardFont struct {
+	// Hash of the font file, part of the cache key of the rendered images.
+	sum  string
+	font *opentype.Font
+}
+
+// loadFont reads the TrueType or OpenType font file at path.
+func loadFont(path string) (*cardFont, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := opentype.Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	sum := sha256.Sum256(b)
+	return &cardFont{
+		sum:  hex.EncodeToString(sum[:]),
+		font: f,
+	}, nil
+}
+
+// face returns the face of the font for the size in pixels. Faces are not
+// safe for concurrent use, so each card uses its own.
+func (f *cardFont) face(size float64) (font.Face, error) {
+	return opentype.NewFace(f.font, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+}
+
+// measure returns the width of the text in pixels.
+func measure(face font.Face, s string) float64 {
+	return fromFixed(font.MeasureString(face, s))
+}
+
+// wrap breaks the text into lines at spaces, so each line fits within the
+// width where possible.
+func wrap(face font.Face, s string, width float64) []string {
+	var lines []string
+	var line string
+	for _, w := range strings.Fields(s) {
+		if line == "" {
+			line = w
+			continue
+		}
+		if measure(face, line+" "+w) > width {
+			lines = append(lines, line)
+			line = w
+			continue
+		}
+		line += " " + w
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// ellipsis shortens the line to fit within the width along with an
+// ellipsis.
+func ellipsis(face font.Face, s string, width float64) string {
+	r := []rune(s)
+	for len(r) > 0 && measure(face, string(r)+"…") > width {
+		r = r[:len(r)-1]
+	}
+	return strings.TrimSpace(string(r)) + "…"
+}
+
+func toFixed(x float64) fixed.Int26_6 {
+	return fixed.Int26_6(x * 64)
+}
+
+func fromFixed(x fixed.Int26_6) float64 {
+	return float64(x) / 64
+}
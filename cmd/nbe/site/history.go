@@ -0,0 +1,86 @@
+package site
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+)
+
+// exampleDates are the dates an example was published and last updated,
+// either may be zero if unknown.
+type exampleDates struct {
+	Published time.Time
+	Updated   time.Time
+}
+
+// gitHistory returns the dates of the first and last commit touching each
+// example under the root, keyed by the example path. An error is returned if
+// the root is not within a git repository.
+func gitHistory(root string) (map[string]*exampleDates, error) {
+	cmd := exec.Command("git", "log", "--format=%x00%cI", "--name-only", "--relative", "--", ".")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git log: %s", bytes.TrimSpace(exitErr.Stderr))
+		}
+		return nil, err
+	}
+
+	dates := make(map[string]*exampleDates)
+	var date time.Time
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "\x00") {
+			date, err = time.Parse(time.RFC3339, line[1:])
+			if err != nil {
+				return nil, err
+			}
+			date = date.UTC()
+			continue
+		}
+
+		// The files are relative to the root, <category>/<example>/...
+		parts := strings.SplitN(line, "/", 3)
+		if len(parts) < 3 || date.IsZero() {
+			continue
+		}
+		key := filepath.Join(root, parts[0], parts[1])
+
+		// The log is ordered from the latest commit.
+		d, ok := dates[key]
+		if !ok {
+			d = &exampleDates{Updated: date}
+			dates[key] = d
+		}
+		d.Published = date
+	}
+	return dates, sc.Err()
+}
+
+// exampleDates returns the dates of the example from the git history, with
+// the published date overridden by the added meta field.
+func (g *generator) exampleDates(e *examples.Example) exampleDates {
+	var d exampleDates
+	if h, ok := g.history[e.Path]; ok {
+		d = *h
+	}
+	if e.Added != "" {
+		if t, err := time.Parse(examples.DateLayout, e.Added); err == nil {
+			d.Published = t
+		}
+	}
+	if d.Updated.Before(d.Published) {
+		d.Updated = d.Published
+	}
+	return d
+}
@@ -0,0 +1,359 @@
+package site
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modes of checking the external links.
+const (
+	// External links are not checked.
+	ExternalSkip = "skip"
+	// External links must be allowed or recorded as valid in the snapshot,
+	// no requests are made.
+	ExternalOffline = "offline"
+	// External links which are not allowed are requested, the results are
+	// recorded to the snapshot if set.
+	ExternalOnline = "online"
+)
+
+// LinkChecker checks the links of the pages of a rendered site. Internal links
+// must point at an existing page or file and, if they have a fragment, an
+// element with that id.
+type LinkChecker struct {
+	// Directory of the rendered site.
+	Dir string
+	// URL the site is served from. Absolute links with this prefix are
+	// checked as internal links.
+	BaseURL string
+	// How external links are checked, ExternalSkip by default.
+	External string
+	// Prefixes of external URLs which are assumed to be valid.
+	Allow []string
+	// Path of the JSON file recording the status of the external URLs. It
+	// is read in offline mode and written in online mode.
+	Snapshot string
+	// Client for the requests in online mode. Defaults to a client with a
+	// timeout of ten seconds.
+	Client *http.Client
+	// Number of concurrent requests in online mode, defaults to 8.
+	Workers int
+}
+
+// BrokenLink is a link of a page which does not resolve.
+type BrokenLink struct {
+	// Page containing the link, relative to the site directory.
+	Page   string `json:"page"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+func (l *BrokenLink) String() string {
+	return fmt.Sprintf("%s: %s: %s", l.Page, l.URL, l.Reason)
+}
+
+// linkStatus is the recorded result of requesting an external URL.
+type linkStatus struct {
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (s *linkStatus) ok() bool {
+	return s.Error == "" && s.Status < 400
+}
+
+func (s *linkStatus) reason() string {
+	if s.Error != "" {
+		return s.Error
+	}
+	return fmt.Sprintf("status %d", s.Status)
+}
+
+type linkSnapshot struct {
+	URLs map[string]*linkStatus `json:"urls"`
+}
+
+var (
+	linkRe = regexp.MustCompile(`<(?:a|link|img|script|source|iframe)\b[^>]*?\s(?:href|src)="([^"]*)"`)
+	idRe   = regexp.MustCompile(`\s(?:id|name)="([^"]*)"`)
+)
+
+// htmlPage is a page of the site with the links and ids it contains.
+type htmlPage struct {
+	links []string
+	ids   map[string]bool
+}
+
+// Run crawls the pages of the site and returns the broken links, sorted by
+// page and URL.
+func (c *LinkChecker) Run() ([]*BrokenLink, error) {
+	mode := c.External
+	if mode == "" {
+		mode = ExternalSkip
+	}
+	switch mode {
+	case ExternalSkip, ExternalOffline, ExternalOnline:
+	default:
+		return nil, fmt.Errorf("unknown external link mode %q, expected %s, %s or %s", mode, ExternalSkip, ExternalOffline, ExternalOnline)
+	}
+
+	pages, err := c.readPages()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		broken   []*BrokenLink
+		external = make(map[string][]string)
+	)
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, link := range pages[name].links {
+			if c.BaseURL != "" && strings.HasPrefix(link, strings.TrimSuffix(c.BaseURL, "/")+"/") {
+				link = strings.TrimPrefix(link, strings.TrimSuffix(c.BaseURL, "/"))
+			}
+			u, err := url.Parse(link)
+			if err != nil {
+				broken = append(broken, &BrokenLink{Page: name, URL: link, Reason: "invalid URL"})
+				continue
+			}
+			switch u.Scheme {
+			case "":
+				if reason := c.checkInternal(pages, name, u); reason != "" {
+					broken = append(broken, &BrokenLink{Page: name, URL: link, Reason: reason})
+				}
+			case "http", "https":
+				if !c.allowed(link) {
+					external[link] = append(external[link], name)
+				}
+			}
+		}
+	}
+
+	if mode != ExternalSkip && len(external) > 0 {
+		statuses, err := c.externalStatuses(mode, external)
+		if err != nil {
+			return nil, err
+		}
+		for link, pages := range external {
+			s, ok := statuses[link]
+			var reason string
+			switch {
+			case !ok && c.Snapshot == "":
+				reason = "not in the allowlist"
+			case !ok:
+				reason = "not in the snapshot"
+			case !s.ok():
+				reason = s.reason()
+			default:
+				continue
+			}
+			for _, p := range pages {
+				broken = append(broken, &BrokenLink{Page: p, URL: link, Reason: reason})
+			}
+		}
+	}
+
+	sort.SliceStable(broken, func(i, j int) bool {
+		if broken[i].Page != broken[j].Page {
+			return broken[i].Page < broken[j].Page
+		}
+		return broken[i].URL < broken[j].URL
+	})
+	return broken, nil
+}
+
+// readPages returns the HTML pages of the site by their slash-separated path
+// relative to the site directory.
+func (c *LinkChecker) readPages() (map[string]*htmlPage, error) {
+	pages := make(map[string]*htmlPage)
+	err := filepath.WalkDir(c.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != ".html" {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(c.Dir, p)
+		if err != nil {
+			return err
+		}
+
+		page := htmlPage{ids: make(map[string]bool)}
+		seen := make(map[string]bool)
+		for _, m := range linkRe.FindAllSubmatch(b, -1) {
+			link := html.UnescapeString(string(m[1]))
+			if !seen[link] {
+				seen[link] = true
+				page.links = append(page.links, link)
+			}
+		}
+		for _, m := range idRe.FindAllSubmatch(b, -1) {
+			page.ids[html.UnescapeString(string(m[1]))] = true
+		}
+		pages[filepath.ToSlash(rel)] = &page
+		return nil
+	})
+	return pages, err
+}
+
+// checkInternal returns why the internal link of the page does not resolve,
+// or an empty string if it does.
+func (c *LinkChecker) checkInternal(pages map[string]*htmlPage, name string, u *url.URL) string {
+	target := name
+	if u.Path != "" {
+		p := u.Path
+		if !strings.HasPrefix(p, "/") {
+			p = path.Join(path.Dir("/"+name), p)
+			if strings.HasSuffix(u.Path, "/") {
+				p += "/"
+			}
+		}
+		p = strings.TrimPrefix(p, "/")
+
+		switch {
+		case p == "" || strings.HasSuffix(p, "/"):
+			target = p + "index.html"
+		case pages[p] != nil:
+			target = p
+		case pages[p+"/index.html"] != nil:
+			target = p + "/index.html"
+		default:
+			if _, err := os.Stat(filepath.Join(c.Dir, filepath.FromSlash(p))); err != nil {
+				return "page not found"
+			}
+			// A file other than a page, e.g. a stylesheet.
+			return ""
+		}
+	}
+
+	page, ok := pages[target]
+	if !ok {
+		return "page not found"
+	}
+	if u.Fragment != "" && !page.ids[u.Fragment] {
+		return fmt.Sprintf("anchor #%s not found", u.Fragment)
+	}
+	return ""
+}
+
+func (c *LinkChecker) allowed(link string) bool {
+	for _, a := range c.Allow {
+		if strings.HasPrefix(link, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// externalStatuses returns the status of the external links, either from
+// the snapshot or by requesting them.
+func (c *LinkChecker) externalStatuses(mode string, external map[string][]string) (map[string]*linkStatus, error) {
+	if mode == ExternalOffline {
+		if c.Snapshot == "" {
+			return map[string]*linkStatus{}, nil
+		}
+		b, err := os.ReadFile(c.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		var s linkSnapshot
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Snapshot, err)
+		}
+		if s.URLs == nil {
+			s.URLs = map[string]*linkStatus{}
+		}
+		return s.URLs, nil
+	}
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	workers := c.Workers
+	if workers <= 0 {
+		workers = 8
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		statuses = make(map[string]*linkStatus)
+		links    = make(chan string)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range links {
+				s := requestLink(client, link)
+				mu.Lock()
+				statuses[link] = s
+				mu.Unlock()
+			}
+		}()
+	}
+	for link := range external {
+		links <- link
+	}
+	close(links)
+	wg.Wait()
+
+	if c.Snapshot != "" {
+		b, err := json.MarshalIndent(&linkSnapshot{URLs: statuses}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(c.Snapshot, append(b, '\n'), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return statuses, nil
+}
+
+// requestLink requests the URL with a HEAD request, falling back to a GET
+// request for servers which do not support it.
+func requestLink(client *http.Client, link string) *linkStatus {
+	var s linkStatus
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, link, nil)
+		if err != nil {
+			return &linkStatus{Error: err.Error()}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			var uerr *url.Error
+			if errors.As(err, &uerr) {
+				err = uerr.Err
+			}
+			s = linkStatus{Error: err.Error()}
+			continue
+		}
+		resp.Body.Close()
+		s = linkStatus{Status: resp.StatusCode}
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+	return &s
+}
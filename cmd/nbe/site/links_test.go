@@ -0,0 +1,72 @@
+package site
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkChecker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"index.html": `<a href="/examples/kv/">KV</a>
+<a href="/examples/kv/intro/go#file-main-go-L3">Intro</a>
+<a href="https://natsbyexample.com/examples/kv/intro/go/">Canonical</a>
+<link rel="stylesheet" href="/main.css">
+<a href="` + srv.URL + `/ok">OK</a>`,
+		"examples/kv/index.html": `<a href="intro/go">Intro</a>
+<a href="../missing/">Missing</a>`,
+		"examples/kv/intro/go/index.html": `<div id="file-main-go-L3"></div>
+<a href="#output">Output</a>
+<a href="` + srv.URL + `/missing">Docs</a>`,
+		"main.css": "",
+	})
+
+	lc := LinkChecker{
+		Dir:     dir,
+		BaseURL: DefaultBaseURL,
+	}
+	broken, err := lc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(broken), 2)
+	checkEqual(t, broken[0].String(), "examples/kv/index.html: ../missing/: page not found")
+	checkEqual(t, broken[1].String(), "examples/kv/intro/go/index.html: #output: anchor #output not found")
+
+	// Record the external links, then check them offline.
+	lc.External = ExternalOnline
+	lc.Snapshot = filepath.Join(t.TempDir(), "links.json")
+	broken, err = lc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(broken), 3)
+	checkEqual(t, broken[2].Reason, "status 404")
+
+	srv.Close()
+	lc.External = ExternalOffline
+	lc.Allow = []string{srv.URL + "/missing"}
+	broken, err = lc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(broken), 2)
+
+	lc.Snapshot = ""
+	lc.Allow = nil
+	broken, err = lc.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, len(broken), 4)
+	checkEqual(t, broken[3].Reason, "not in the allowlist")
+}
@@ -0,0 +1,208 @@
+package site
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryFile is a file of the site kept in memory.
+type memoryFile struct {
+	// Cache key of the inputs the file was rendered from.
+	key  string
+	body []byte
+	// Entity tag of the body and the time it last changed.
+	etag    string
+	modTime time.Time
+}
+
+func newMemoryFile(key string, body []byte, modTime time.Time) *memoryFile {
+	sum := sha256.Sum256(body)
+	return &memoryFile{
+		key:     key,
+		body:    body,
+		etag:    `"` + hex.EncodeToString(sum[:16]) + `"`,
+		modTime: modTime,
+	}
+}
+
+// memoryOutput keeps the files of the site in memory. Each pass of the
+// generator renders only the wanted file, if it is missing or its inputs
+// changed, and records the keys of all others so later requests can be
+// answered without a pass. Passes run concurrently, outside of the lock.
+type memoryOutput struct {
+	global string
+
+	mu sync.Mutex
+	// Pass of the generator over the examples last loaded.
+	pass func(out siteOutput) error
+	// Keys of the files of the site, nil before the first pass. The files
+	// skipped by the passes so far have an empty key.
+	keys  map[string]string
+	files map[string]*memoryFile
+	// Incremented whenever the examples are loaded, so the keys of passes
+	// over the previous ones are discarded.
+	version int
+}
+
+func newMemoryOutput(global string) *memoryOutput {
+	return &memoryOutput{
+		global: global,
+		files:  make(map[string]*memoryFile),
+	}
+}
+
+// reset sets the pass of the generator over the examples loaded again. The
+// files are kept, so those whose inputs did not change keep their ETag.
+func (m *memoryOutput) reset(pass func(out siteOutput) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pass = pass
+	m.keys = nil
+	m.version++
+}
+
+// memoryPass is the output of a pass rendering the wanted file of the site
+// in memory.
+type memoryPass struct {
+	m    *memoryOutput
+	want string
+	keys map[string]string
+}
+
+func (p *memoryPass) Fresh(path string, inputs ...any) (bool, error) {
+	key, err := fileCacheKey(p.m.global, path, inputs...)
+	if err != nil {
+		return false, err
+	}
+	path = memoryPath(path)
+	p.keys[path] = key
+
+	if path != p.want {
+		return true, nil
+	}
+	p.m.mu.Lock()
+	defer p.m.mu.Unlock()
+	f, ok := p.m.files[path]
+	return ok && f.key == key, nil
+}
+
+// Skip records the file at path without its key, unless it is wanted.
+func (p *memoryPass) Skip(path string) bool {
+	path = memoryPath(path)
+	if path == p.want {
+		return false
+	}
+	if _, ok := p.keys[path]; !ok {
+		p.keys[path] = ""
+	}
+	return true
+}
+
+// Write keeps the file, its modification time is only updated if the
+// contents changed.
+func (p *memoryPass) Write(path string, b []byte) error {
+	path = memoryPath(path)
+	key := p.keys[path]
+
+	p.m.mu.Lock()
+	defer p.m.mu.Unlock()
+	if f, ok := p.m.files[path]; ok && bytes.Equal(f.body, b) {
+		f.key = key
+		return nil
+	}
+	p.m.files[path] = newMemoryFile(key, append([]byte(nil), b...), time.Now())
+	return nil
+}
+
+// memoryPath returns the slash-separated path of a file relative to the root
+// of the site.
+func memoryPath(p string) string {
+	return strings.TrimPrefix(filepath.ToSlash(p), "/")
+}
+
+// render runs a pass of the generator if the file at path is missing or
+// stale, returning the file or nil if the site has no such file.
+func (m *memoryOutput) render(path string) (*memoryFile, error) {
+	m.mu.Lock()
+	if m.keys != nil {
+		key, ok := m.keys[path]
+		if !ok {
+			m.mu.Unlock()
+			return nil, nil
+		}
+		if f, ok := m.files[path]; ok && key != "" && f.key == key {
+			m.mu.Unlock()
+			return f, nil
+		}
+	}
+	pass, version := m.pass, m.version
+	m.mu.Unlock()
+
+	p := &memoryPass{
+		m:    m,
+		want: path,
+		keys: make(map[string]string),
+	}
+	if err := pass(p); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// The passes over the same examples record the same keys, except for
+	// the skipped files.
+	if m.version == version {
+		if m.keys == nil {
+			m.keys = make(map[string]string)
+		}
+		for k, v := range p.keys {
+			if _, ok := m.keys[k]; !ok || v != "" {
+				m.keys[k] = v
+			}
+		}
+		if _, ok := p.keys[path]; !ok {
+			delete(m.keys, path)
+		}
+	}
+	return m.files[path], nil
+}
+
+// exists returns true if the site has the file at path according to the
+// passes so far.
+func (m *memoryOutput) exists(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.keys[path]
+	return ok
+}
+
+// readMemoryFiles reads the files of the directory by name, like the static
+// files copied by Builder.Run.
+func readMemoryFiles(dir string) (map[string]*memoryFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*memoryFile)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		files[e.Name()] = newMemoryFile("", b, info.ModTime())
+	}
+	return files, nil
+}
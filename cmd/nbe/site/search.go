@@ -0,0 +1,229 @@
+package site
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+)
+
+// Path of the search index in the output directory.
+const searchIndexFile = "search-index.json"
+
+// searchIndex is queried by the search page in static/main.js. The keys are
+// abbreviated to keep the file small.
+type searchIndex struct {
+	// Languages shown as filters on the search page.
+	Languages []*searchLanguage `json:"languages"`
+	Examples  []*searchExample  `json:"examples"`
+	Docs      []*searchDoc      `json:"docs"`
+}
+
+type searchLanguage struct {
+	Name  string `json:"n"`
+	Label string `json:"l"`
+}
+
+type searchExample struct {
+	Title    string `json:"t"`
+	Category string `json:"c"`
+	// Path of the first client of the example.
+	Path        string `json:"p"`
+	Description string `json:"d,omitempty"`
+	// Tags and features of the example.
+	Keywords []string `json:"k,omitempty"`
+	// Languages of the clients of the example.
+	Languages []string `json:"ls"`
+}
+
+// searchDoc is a comment block of a client file along with the code following
+// it, or a code block on its own.
+type searchDoc struct {
+	// Index of the example in searchIndex.Examples.
+	Example  int    `json:"e"`
+	Language string `json:"l"`
+	Path     string `json:"p"`
+	// HTML id of the block.
+	Anchor string `json:"a"`
+	// Prose of the comment block, if any.
+	Text string `json:"x,omitempty"`
+	// Identifiers within the code.
+	Idents []string `json:"i,omitempty"`
+}
+
+// searchIndex builds the search index from the titles, descriptions and
+// visible blocks of the examples.
+func (g *generator) searchIndex(root *examples.Root) *searchIndex {
+	var idx searchIndex
+
+	for _, c := range root.Categories {
+		for _, e := range c.Examples {
+			clients := e.SortedClients(g.langs)
+
+			kws := append(append([]string{}, e.Tags...), e.Features...)
+			sx := searchExample{
+				Title:       e.Title,
+				Category:    c.Title,
+				Path:        g.examplePath(e),
+				Description: plainText(e.Description),
+				Keywords:    kws,
+			}
+			for _, i := range clients {
+				sx.Languages = append(sx.Languages, i.Language)
+			}
+			idx.Examples = append(idx.Examples, &sx)
+			n := len(idx.Examples) - 1
+
+			for _, i := range clients {
+				idx.Docs = append(idx.Docs, g.searchDocs(n, i.Language, i.Path, fileID(i.MainFile), i.Language, i.Blocks)...)
+				for _, f := range i.Files {
+					idx.Docs = append(idx.Docs, g.searchDocs(n, i.Language, i.Path, fileID(f.Name), f.Language, f.Blocks)...)
+				}
+			}
+		}
+	}
+
+	for _, l := range g.langs.Tabs() {
+		idx.Languages = append(idx.Languages, &searchLanguage{
+			Name:  l.Name,
+			Label: l.Label,
+		})
+	}
+
+	return &idx
+}
+
+// searchDocs returns a document for each comment block of a file, with the
+// identifiers of the code following it, and for each code block which does
+// not follow a comment.
+func (g *generator) searchDocs(example int, client, path, fid, lang string, blocks []*examples.Block) []*searchDoc {
+	var (
+		docs []*searchDoc
+		doc  *searchDoc
+	)
+	for _, b := range blocks {
+		if b.Hidden {
+			continue
+		}
+		switch b.Type {
+		case examples.SingleLineCommentBlock, examples.MultiLineCommentBlock:
+			text, _ := g.commentText(lang, b)
+			doc = &searchDoc{
+				Example:  example,
+				Language: client,
+				Path:     path,
+				Anchor:   blockID(fid, b),
+				Text:     plainText(text),
+			}
+			docs = append(docs, doc)
+
+		case examples.CodeBlock:
+			if doc == nil {
+				doc = &searchDoc{
+					Example:  example,
+					Language: client,
+					Path:     path,
+					Anchor:   blockID(fid, b),
+				}
+				docs = append(docs, doc)
+			}
+			doc.Idents = appendIdents(doc.Idents, b.Lines)
+			doc = nil
+		}
+	}
+	return docs
+}
+
+var (
+	identRe = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]{2,}`)
+
+	mdLinkRe     = regexp.MustCompile(`\[([^\]]*)\](\([^)]*\)|\[[^\]]*\])`)
+	mdRefRe      = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]:\s*\S+.*$`)
+	htmlTagRe    = regexp.MustCompile(`<[^>]+>`)
+	mdPrefixRe   = regexp.MustCompile(`(?m)^\s*(#+|>|-|\d+\.)\s`)
+	mdSyntaxRe   = regexp.MustCompile("[`*|]+")
+	whitespaceRe = regexp.MustCompile(`\s+`)
+)
+
+// appendIdents appends the identifiers of at least three characters within
+// the lines which are not in ids yet.
+func appendIdents(ids []string, lines []string) []string {
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, l := range lines {
+		for _, id := range identRe.FindAllString(l, -1) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// plainText strips the markdown syntax and HTML tags from the text and
+// collapses the whitespace.
+func plainText(md string) string {
+	s := mdRefRe.ReplaceAllString(md, "")
+	s = mdLinkRe.ReplaceAllString(s, "$1")
+	s = htmlTagRe.ReplaceAllString(s, " ")
+	s = mdPrefixRe.ReplaceAllString(s, "")
+	s = mdSyntaxRe.ReplaceAllString(s, "")
+	s = whitespaceRe.ReplaceAllString(s, " ")
+	return strings.TrimSpace(s)
+}
+
+type searchData struct {
+	PageTitle    string
+	CanonicalURL template.URL
+	Languages    []*searchLanguage
+}
+
+// generateSearch writes the search index and the search page to the output.
+func (g *generator) generateSearch(root *examples.Root, out siteOutput, st *template.Template) error {
+	page := filepath.Join("search", "index.html")
+	if skipFiles(out, searchIndexFile, page) {
+		return nil
+	}
+	idx := g.searchIndex(root)
+
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", searchIndexFile, err)
+	}
+	fresh, err := out.Fresh(searchIndexFile, b)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		if err := out.Write(searchIndexFile, b); err != nil {
+			return err
+		}
+	}
+
+	sd := searchData{
+		PageTitle:    g.config.pageTitle("Search"),
+		CanonicalURL: template.URL(g.pageURL("search")),
+		Languages:    idx.Languages,
+	}
+	fresh, err = out.Fresh(page, &sd)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		return nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := st.Execute(buf, &sd); err != nil {
+		return err
+	}
+	return out.Write(page, buf.Bytes())
+}
@@ -0,0 +1,71 @@
+package site
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/examples"
+	"github.com/ConnectEverything/nats-by-example/cmd/nbe/lang"
+)
+
+func TestSearchDocs(t *testing.T) {
+	src := `package main
+
+// <!hide>
+import "os"
+
+// <!show>
+
+// Connect to the [server](https://nats.io) using **NATS_URL**.
+func main() {
+	nc, _ := nats.Connect(os.Getenv("NATS_URL"))
+	defer nc.Drain()
+}
+`
+	p := examples.Parser{Languages: lang.Default}
+	blocks, _, err := p.ParseSource(lang.Go, strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := generator{langs: lang.Default}
+	docs := g.searchDocs(1, lang.Go, "examples/messaging/pub-sub/go", "file-main-go", lang.Go, blocks)
+
+	expected := []*searchDoc{
+		{
+			Example:  1,
+			Language: lang.Go,
+			Path:     "examples/messaging/pub-sub/go",
+			Anchor:   "file-main-go-L1",
+			Idents:   []string{"package", "main"},
+		},
+		{
+			Example:  1,
+			Language: lang.Go,
+			Path:     "examples/messaging/pub-sub/go",
+			Anchor:   "file-main-go-L7",
+			Text:     "Connect to the server using NATS_URL.",
+			Idents:   []string{"func", "main", "nats", "Connect", "Getenv", "NATS_URL", "defer", "Drain"},
+		},
+	}
+	if diff := cmp.Diff(expected, docs); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	input := `## Streams
+Use a [stream][1] to _persist_ **messages**, see ` + "`js.AddStream`" + `.
+
+- <b>One</b>
+- Two
+
+[1]: https://docs.nats.io/nats-concepts/jetstream/streams`
+
+	expected := "Streams Use a stream to _persist_ messages, see js.AddStream. One Two"
+	if diff := cmp.Diff(expected, plainText(input)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,341 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Path of the Server-Sent Events stream notifying the pages to reload.
+const reloadPath = "/_nbe/reload"
+
+// reloadScript is injected into the pages served in watch mode.
+const reloadScript = `<script>new EventSource("` + reloadPath + `").addEventListener("reload", function () { location.reload(); });</script>`
+
+var errorPage = template.Must(template.New("error").Funcs(template.FuncMap{
+	"reload": func() template.HTML { return reloadScript },
+}).Parse(`<!doctype html>
+<html>
+<head>
+  <title>Build failed</title>
+  <style>
+    body { margin: 0; font-family: sans-serif; background: #fafafa; }
+    .overlay { max-width: 900px; margin: 40px auto; padding: 20px 30px; background: #fff; border-top: 4px solid #c62828; box-shadow: 0 2px 8px rgba(0, 0, 0, 0.15); }
+    h1 { font-size: 1.3em; color: #c62828; }
+    pre { white-space: pre-wrap; font-size: 0.9em; }
+  </style>
+</head>
+<body>
+  <div class="overlay">
+    <h1>Build failed</h1>
+    <pre>{{.}}</pre>
+    <p><small>The page reloads once the error is fixed.</small></p>
+  </div>
+  {{reload}}
+</body>
+</html>
+`))
+
+// Server serves the site for development. If InMemory is set, the pages are
+// rendered on demand from the examples and kept in memory, otherwise the
+// output directory of the Builder is served. If Watch is set, the site is
+// rebuilt in-process whenever the examples, static files or theme change,
+// and the open pages reload themselves. Build errors are shown as a page
+// rather than stopping the server.
+type Server struct {
+	// Builder of the site, whose output directory is served unless
+	// InMemory is set.
+	Builder *Builder
+	// If true, the pages are rendered on demand rather than built to the
+	// output directory.
+	InMemory bool
+	// If true, the site is built on start and whenever the sources change.
+	Watch bool
+	// Interval the sources are checked for changes at, defaults to half a
+	// second.
+	Interval time.Duration
+
+	mu      sync.Mutex
+	err     error
+	clients map[chan struct{}]bool
+
+	// State of the site rendered in memory.
+	out    *memoryOutput
+	static map[string]*memoryFile
+}
+
+// ListenAndServe serves the site on the address. In watch and in-memory
+// mode the site is built first.
+func (s *Server) ListenAndServe(addr string) error {
+	if s.Watch || s.InMemory {
+		s.build()
+	}
+	if s.Watch {
+		go s.watch(nil)
+	}
+	return http.ListenAndServe(addr, s)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dir := s.Builder.Output
+	if !s.Watch && !s.InMemory {
+		http.FileServer(http.Dir(dir)).ServeHTTP(w, r)
+		return
+	}
+	if s.Watch && r.URL.Path == reloadPath {
+		s.serveEvents(w, r)
+		return
+	}
+	if s.InMemory {
+		s.serveMemory(w, r)
+		return
+	}
+
+	// Only pages are replaced by the error and get the reload script.
+	p := r.URL.Path
+	if strings.HasSuffix(p, "/") {
+		p += "index.html"
+	}
+	if path.Ext(p) != ".html" {
+		http.FileServer(http.Dir(dir)).ServeHTTP(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	err := s.err
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path.Clean(p))))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(injectReload(b))
+}
+
+// serveMemory serves a file of the site rendered in memory, rendering it
+// first if needed. Requests with a matching ETag or modification time are
+// answered with 304 Not Modified.
+func (s *Server) serveMemory(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	page := path.Ext(name) == ".html"
+
+	// The page is rendered without holding the lock, so other requests are
+	// not blocked by the pass.
+	s.mu.Lock()
+	err, out, f := s.err, s.out, s.static[name]
+	s.mu.Unlock()
+	if err != nil && page {
+		writeError(w, err)
+		return
+	}
+
+	if f == nil && out != nil {
+		f, err = out.render(name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if f == nil && path.Ext(name) == "" && out.exists(name+"/index.html") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+	}
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	body := f.body
+	if s.Watch && page {
+		body = injectReload(body)
+	}
+	w.Header().Set("ETag", f.etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, name, f.modTime, bytes.NewReader(body))
+}
+
+// injectReload adds the reload script to the end of the body of the page.
+func injectReload(b []byte) []byte {
+	i := bytes.LastIndex(b, []byte("</body>"))
+	if i < 0 {
+		return append(b[:len(b):len(b)], reloadScript...)
+	}
+	return append(b[:i:i], append([]byte(reloadScript), b[i:]...)...)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	errorPage.Execute(w, err.Error())
+}
+
+// serveEvents streams a reload event to the page after each build.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	if s.clients == nil {
+		s.clients = make(map[chan struct{}]bool)
+	}
+	s.clients[ch] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// build builds the site, keeping the error to be shown in place of the
+// pages, and notifies the open pages.
+func (s *Server) build() {
+	start := time.Now()
+	logger := s.Builder.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	if s.InMemory {
+		err = s.load()
+	} else {
+		err = s.Builder.Run()
+	}
+	if err != nil {
+		logger.Printf("build failed: %s", err)
+	} else {
+		logger.Printf("built in %s", time.Since(start).Round(time.Millisecond))
+	}
+
+	s.err = err
+	for ch := range s.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// load parses the examples and reads the static files for rendering the
+// site in memory. The rendered files are kept, so those whose inputs did not
+// change keep their ETag and modification time.
+func (s *Server) load() error {
+	g, err := s.Builder.generator()
+	if err != nil {
+		return err
+	}
+	root, err := g.parse(s.Builder.Source)
+	if err != nil {
+		return err
+	}
+	s.Builder.loadHistory(g, root)
+
+	global, err := globalCacheKey(g.langs.All(), g.config, g.theme)
+	if err != nil {
+		return err
+	}
+	static, err := readMemoryFiles(s.Builder.Static)
+	if err != nil {
+		return err
+	}
+
+	s.static = static
+	if s.out == nil || s.out.global != global {
+		s.out = newMemoryOutput(global)
+	}
+	s.out.reset(func(out siteOutput) error {
+		return g.generateDocs(root, out)
+	})
+	return nil
+}
+
+// watch polls the sources, rebuilding the site when they change until stop
+// is closed.
+func (s *Server) watch(stop <-chan struct{}) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	dirs := []string{s.Builder.Source, s.Builder.Static, s.Builder.Theme}
+
+	last := dirsStamp(dirs...)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if stamp := dirsStamp(dirs...); stamp != last {
+			last = stamp
+			s.build()
+		}
+	}
+}
+
+// dirsStamp returns a hash of the paths, sizes and modification times of the
+// files in the directories, which changes when any file is added, removed or
+// written. Unset and missing directories are ignored.
+func dirsStamp(dirs ...string) uint64 {
+	h := fnv.New64a()
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(h, "%s %d %d\n", p, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,194 @@
+package site
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServerWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, pubSubTree(nil))
+	write := func(name, content string) {
+		writeTree(t, dir, map[string]string{name: content})
+	}
+
+	s := Server{
+		Builder: &Builder{
+			Source: filepath.Join(dir, "examples"),
+			Static: filepath.Join(dir, "static"),
+			Output: filepath.Join(dir, "html"),
+			Logger: log.New(io.Discard, "", 0),
+		},
+		Watch:    true,
+		Interval: 10 * time.Millisecond,
+	}
+	s.build()
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.watch(stop)
+
+	ts := httptest.NewServer(&s)
+	defer ts.Close()
+
+	page := "/" + filepath.ToSlash(s.Builder.Source) + "/messaging/pub-sub/go/"
+	get := func() (int, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(b)
+	}
+
+	status, body := get()
+	checkEqual(t, status, http.StatusOK)
+	checkEqual(t, strings.Contains(body, reloadScript+"</body>"), true)
+
+	resp, err := http.Get(ts.URL + reloadPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := make(chan string)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			if strings.HasPrefix(sc.Text(), "event: ") {
+				events <- sc.Text()
+			}
+		}
+	}()
+	waitReload := func() {
+		t.Helper()
+		select {
+		case e := <-events:
+			checkEqual(t, e, "event: reload")
+		case <-time.After(5 * time.Second):
+			t.Fatal("no reload event")
+		}
+	}
+
+	// A parse error is shown in place of the page.
+	write("examples/messaging/pub-sub/go/main.go", "package main\n\n*/\nfunc main() {}\n")
+	waitReload()
+	status, body = get()
+	checkEqual(t, status, http.StatusInternalServerError)
+	checkEqual(t, strings.Contains(body, "unbalanced close of multi-line comment"), true)
+
+	write("examples/messaging/pub-sub/go/main.go", "package main\n\n// Connect to the server.\nfunc main() {}\n")
+	waitReload()
+	status, body = get()
+	checkEqual(t, status, http.StatusOK)
+	checkEqual(t, strings.Contains(body, "Connect to the server."), true)
+}
+
+func TestServerInMemory(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, pubSubTree(nil))
+
+	var logs bytes.Buffer
+	s := Server{
+		Builder: &Builder{
+			Source: filepath.Join(dir, "examples"),
+			Static: filepath.Join(dir, "static"),
+			Output: filepath.Join(dir, "html"),
+			Logger: log.New(&logs, "", 0),
+		},
+		InMemory: true,
+	}
+	s.build()
+	if s.err != nil {
+		t.Fatal(s.err)
+	}
+
+	ts := httptest.NewServer(&s)
+	defer ts.Close()
+	client := ts.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	get := func(p, etag string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, ts.URL+p, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	page := "/" + filepath.ToSlash(s.Builder.Source) + "/messaging/pub-sub/go"
+	resp := get(page, "")
+	checkEqual(t, resp.StatusCode, http.StatusMovedPermanently)
+	checkEqual(t, resp.Header.Get("Location"), page+"/")
+
+	resp = get(page+"/", "")
+	checkEqual(t, resp.StatusCode, http.StatusOK)
+	etag := resp.Header.Get("ETag")
+	checkEqual(t, etag != "", true)
+	checkEqual(t, resp.Header.Get("Last-Modified") != "", true)
+
+	resp = get(page+"/", etag)
+	checkEqual(t, resp.StatusCode, http.StatusNotModified)
+
+	checkEqual(t, get("/main.css", "").StatusCode, http.StatusOK)
+	checkEqual(t, get("/search-index.json", "").StatusCode, http.StatusOK)
+	checkEqual(t, get("/missing/", "").StatusCode, http.StatusNotFound)
+	checkEqual(t, get("/atom.xml", "").StatusCode, http.StatusOK)
+	checkEqual(t, get("/sitemap.xml", "").StatusCode, http.StatusOK)
+
+	// An unchanged page keeps its ETag when the examples are loaded again.
+	s.build()
+	resp = get(page+"/", etag)
+	checkEqual(t, resp.StatusCode, http.StatusNotModified)
+	checkEqual(t, strings.Count(logs.String(), "no git history"), 1)
+
+	// Pages are rendered concurrently.
+	var wg sync.WaitGroup
+	for _, p := range []string{"/", page + "/", "/search/", "/search-index.json", "/sitemap.xml"} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(p string) {
+				defer wg.Done()
+				resp, err := client.Get(ts.URL + p)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("%s: status %d", p, resp.StatusCode)
+				}
+			}(p)
+		}
+	}
+	wg.Wait()
+
+	// Nothing is written to the output directory.
+	if _, err := os.Stat(s.Builder.Output); !os.IsNotExist(err) {
+		t.Errorf("expected no output directory, got %v", err)
+	}
+}
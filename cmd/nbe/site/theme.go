@@ -0,0 +1,89 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// Stylesheet of the syntax highlighting style in the output directory.
+const stylesFile = "chroma.css"
+
+// loadTheme returns the templates of the theme directory by name, i.e. the
+// file name without the .html extension.
+func loadTheme(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("theme: %w", err)
+	}
+
+	theme := make(map[string]string)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".html" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("theme: %w", err)
+		}
+		theme[strings.TrimSuffix(e.Name(), ".html")] = string(b)
+	}
+	return theme, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkStyle returns an error if the chroma style does not exist.
+func checkStyle(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := styles.Registry[strings.ToLower(name)]; !ok {
+		return fmt.Errorf("unknown highlight style %q", name)
+	}
+	return nil
+}
+
+// generateStyles writes the stylesheet of the configured highlight styles.
+// The dark style applies if the browser prefers a dark color scheme. Without
+// a configured style the highlighting of main.css is used.
+func (g *generator) generateStyles(out siteOutput) error {
+	h := g.config.Highlight
+	if h.Style == "" && h.DarkStyle == "" {
+		return nil
+	}
+
+	formatter := html.New(html.WithClasses(true))
+	buf := bytes.NewBuffer(nil)
+	if h.Style != "" {
+		if err := formatter.WriteCSS(buf, styles.Get(h.Style)); err != nil {
+			return err
+		}
+	}
+	if h.DarkStyle != "" {
+		buf.WriteString("@media (prefers-color-scheme: dark) {\n")
+		if err := formatter.WriteCSS(buf, styles.Get(h.DarkStyle)); err != nil {
+			return err
+		}
+		buf.WriteString("}\n")
+	}
+
+	fresh, err := out.Fresh(stylesFile, buf.Bytes())
+	if err != nil || fresh {
+		return err
+	}
+	return out.Write(stylesFile, buf.Bytes())
+}
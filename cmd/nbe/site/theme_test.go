@@ -0,0 +1,49 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildTheme(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, pubSubTree(map[string]string{
+		"theme/logo.html":  `<h1 id="header">{{template "brand"}}</h1>`,
+		"theme/brand.html": `Acme Examples`,
+		"theme/README.md":  "Not a template.",
+		// The partials of the embedded pages remain available.
+		"theme/client.html": `{{template "head" .}}
+{{template "logo" .}}
+{{range .Files}}{{range .Groups}}{{range .Blocks}}{{template "block-links" .}}{{end}}{{end}}{{end}}`,
+	}))
+
+	config := DefaultConfig()
+	config.Highlight = HighlightConfig{Style: "github", DarkStyle: "github-dark"}
+
+	b := Builder{
+		Source: filepath.Join(dir, "examples"),
+		Static: filepath.Join(dir, "static"),
+		Output: filepath.Join(dir, "html"),
+		Theme:  filepath.Join(dir, "theme"),
+		Config: config,
+	}
+	if err := b.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(b.Output, b.Source, "messaging/pub-sub/go/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, strings.Contains(string(page), `<h1 id="header">Acme Examples</h1>`), true)
+	checkEqual(t, strings.Contains(string(page), `class="permalink"`), true)
+	checkEqual(t, strings.Contains(string(page), `href="/chroma.css"`), true)
+
+	css, err := os.ReadFile(filepath.Join(b.Output, stylesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEqual(t, strings.Contains(string(css), "@media (prefers-color-scheme: dark)"), true)
+}
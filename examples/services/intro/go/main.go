@@ -1,3 +1,4 @@
+// <!collapse title="Imports and connection setup">
 package main
 
 import (
@@ -35,6 +36,7 @@ func main() {
 		return
 	}
 
+	// <!show>
 	// ### Defining a Service
 	//
 	// This will create a service definition. Service definitions are made up of
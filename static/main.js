@@ -13,4 +13,203 @@
   new Clipboard('.copy-code', {
     text: function (trigger) {return codeNoComments;}
   });
+  // The text of the output is in the data-clipboard-text attribute.
+  new Clipboard('.copy-output').on('success', function (e) {
+    e.trigger.classList.add('copied');
+    setTimeout(function () {e.trigger.classList.remove('copied');}, 1500);
+  });
+})();
+
+(function () {
+  var tabs = document.querySelectorAll('.file-tabs a');
+  if (tabs.length === 0) {
+    return;
+  }
+
+  function show(id) {
+    var found = false;
+    tabs.forEach(function (tab) {
+      var active = tab.getAttribute('href') === '#' + id;
+      tab.classList.toggle('active', active);
+      found = found || active;
+    });
+    if (!found) {
+      return false;
+    }
+    document.querySelectorAll('.example-file').forEach(function (file) {
+      file.classList.toggle('inactive', file.id !== id);
+    });
+    return true;
+  }
+
+  tabs.forEach(function (tab) {
+    tab.addEventListener('click', function (e) {
+      e.preventDefault();
+      var id = tab.getAttribute('href').slice(1);
+      history.replaceState(null, '', '#' + id);
+      show(id);
+    });
+  });
+
+  // Shows the file containing the block the hash points at, if any.
+  function showBlock(id) {
+    var el = id && document.getElementById(id);
+    var file = el && el.closest('.example-file');
+    if (!file || !show(file.id)) {
+      return false;
+    }
+    if (el !== file) {
+      el.scrollIntoView();
+    }
+    return true;
+  }
+
+  window.addEventListener('hashchange', function () {
+    showBlock(window.location.hash.slice(1));
+  });
+
+  if (!showBlock(window.location.hash.slice(1))) {
+    show(tabs[0].getAttribute('href').slice(1));
+  }
+})();
+
+(function () {
+  var form = document.querySelector('.search-form');
+  var results = document.querySelector('.search-results');
+  if (!form || !results) {
+    return;
+  }
+  var input = form.querySelector('input[name=q]');
+  var select = form.querySelector('select[name=lang]');
+
+  var params = new URLSearchParams(window.location.search);
+  input.value = params.get('q') || '';
+  select.value = params.get('lang') || '';
+
+  var index = null;
+
+  function tokens(s) {
+    return s.toLowerCase().split(/[^a-z0-9_]+/).filter(function (t) {
+      return t.length > 0;
+    });
+  }
+
+  // Scores a document against the query tokens, every token must match.
+  function score(doc, ex, terms) {
+    var total = 0;
+    var title = ex.t.toLowerCase();
+    var desc = (ex.d || '').toLowerCase();
+    var text = (doc.x || '').toLowerCase();
+    var keywords = (ex.k || []).map(function (k) {return k.toLowerCase();});
+    var idents = (doc.i || []).map(function (i) {return i.toLowerCase();});
+
+    for (var i = 0; i < terms.length; i++) {
+      var t = terms[i];
+      var s = 0;
+      if (title.indexOf(t) >= 0) s += 10;
+      if (keywords.some(function (k) {return k.indexOf(t) >= 0;})) s += 6;
+      if (idents.indexOf(t) >= 0) {
+        s += 8;
+      } else if (idents.some(function (id) {return id.indexOf(t) >= 0;})) {
+        s += 4;
+      }
+      if (text.indexOf(t) >= 0) s += 2;
+      if (desc.indexOf(t) >= 0) s += 1;
+      if (s === 0) {
+        return 0;
+      }
+      total += s;
+    }
+    return total;
+  }
+
+  function snippet(text, terms) {
+    if (!text) {
+      return '';
+    }
+    var lower = text.toLowerCase();
+    var at = -1;
+    for (var i = 0; i < terms.length && at < 0; i++) {
+      at = lower.indexOf(terms[i]);
+    }
+    var start = Math.max(0, at - 60);
+    var s = text.slice(start, start + 200);
+    return (start > 0 ? '…' : '') + s + (start + 200 < text.length ? '…' : '');
+  }
+
+  function render() {
+    var terms = tokens(input.value);
+    var lang = select.value;
+    results.innerHTML = '';
+    if (!index || terms.length === 0) {
+      return;
+    }
+
+    var labels = {};
+    index.languages.forEach(function (l) {labels[l.n] = l.l;});
+
+    var hits = [];
+    index.docs.forEach(function (doc) {
+      if (lang && doc.l !== lang) {
+        return;
+      }
+      var s = score(doc, index.examples[doc.e], terms);
+      if (s > 0) {
+        hits.push({doc: doc, score: s});
+      }
+    });
+    hits.sort(function (a, b) {return b.score - a.score;});
+
+    if (hits.length === 0) {
+      var empty = document.createElement('li');
+      empty.className = 'quiet';
+      empty.textContent = 'No results.';
+      results.appendChild(empty);
+      return;
+    }
+
+    hits.slice(0, 50).forEach(function (hit) {
+      var doc = hit.doc;
+      var ex = index.examples[doc.e];
+      var li = document.createElement('li');
+      var a = document.createElement('a');
+      a.href = '/' + doc.p + '#' + doc.a;
+      a.textContent = ex.t;
+      li.appendChild(a);
+      var meta = document.createElement('small');
+      meta.className = 'quiet';
+      meta.textContent = ' ' + ex.c + ' · ' + (labels[doc.l] || doc.l);
+      li.appendChild(meta);
+      var text = snippet(doc.x, terms);
+      if (text) {
+        var p = document.createElement('p');
+        p.textContent = text;
+        li.appendChild(p);
+      }
+      results.appendChild(li);
+    });
+  }
+
+  function update() {
+    var params = new URLSearchParams();
+    if (input.value) params.set('q', input.value);
+    if (select.value) params.set('lang', select.value);
+    var qs = params.toString();
+    history.replaceState(null, '', window.location.pathname + (qs ? '?' + qs : ''));
+    render();
+  }
+
+  form.addEventListener('submit', function (e) {
+    e.preventDefault();
+    update();
+  });
+  input.addEventListener('input', update);
+  select.addEventListener('change', update);
+
+  fetch('/search-index.json')
+    .then(function (res) {return res.json();})
+    .then(function (data) {
+      index = data;
+      render();
+    });
 })();